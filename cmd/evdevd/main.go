@@ -1,4 +1,215 @@
+// Package evdevd grabs an evdev device and re-emits its events through a
+// uinput clone after passing them through a filter.
 package main
 
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"log"
+	"maps"
+	"os"
+	"os/signal"
+	"slices"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/andrieee44/gopkg/linux/evdev"
+	"github.com/andrieee44/gopkg/linux/pipeline"
+	"github.com/andrieee44/gopkg/linux/uapi/input"
+	"github.com/andrieee44/gopkg/linux/uinput"
+)
+
+// errUsage reports invalid arguments, after the usage has been printed.
+var errUsage error = errors.New("invalid arguments")
+
+func usageFn(fs *flag.FlagSet) func() {
+	return func() {
+		_, _ = fmt.Fprintf(fs.Output(), `Usage:
+  %s debounce [-threshold <duration>] [-deferred] [-key <KEY>=<duration>]... <evdev-file>
+`, os.Args[0])
+
+		fs.PrintDefaults()
+	}
+}
+
+// parseKeyThreshold parses a -key flag of the form KEY_A=40ms, also
+// accepting the key name without its KEY_ prefix.
+func parseKeyThreshold(keys map[input.KeyCode]time.Duration) func(string) error {
+	return func(value string) error {
+		var (
+			name, duration string
+			key            input.KeyCode
+			threshold      time.Duration
+			found          bool
+			err            error
+		)
+
+		name, duration, found = strings.Cut(value, "=")
+		if !found {
+			return fmt.Errorf("missing = in %q", value)
+		}
+
+		key, err = input.KeyCodeString(name)
+		if err != nil {
+			key, err = input.KeyCodeString("KEY_" + name)
+		}
+
+		if err != nil {
+			return fmt.Errorf("unknown key %s", name)
+		}
+
+		threshold, err = time.ParseDuration(duration)
+		if err != nil {
+			return err
+		}
+
+		keys[key] = threshold
+
+		return nil
+	}
+}
+
+// run clones source as a uinput device, grabs source and passes its
+// events through chain until ctx is cancelled. The clone leaves out
+// EV_REP, as the key repeats of source are passed on. Errors closing
+// the devices are joined with the error returned.
+func run(ctx context.Context, path string, chain pipeline.Chain) (err error) {
+	var (
+		source *evdev.Device
+		snap   *evdev.Snapshot
+		sink   *uinput.Device
+	)
+
+	source, err = evdev.NewDevice(path)
+	if err != nil {
+		return err
+	}
+
+	defer func() {
+		err = errors.Join(err, source.Close())
+	}()
+
+	snap, err = source.Snapshot()
+	if err != nil {
+		return err
+	}
+
+	sink, err = uinput.NewDeviceFromSnapshot(snap, &uinput.SnapshotOptions{
+		DropEvents: []input.EventCode{input.EV_FF, input.EV_REP},
+	})
+	if err != nil {
+		return err
+	}
+
+	defer func() {
+		err = errors.Join(err, sink.Close())
+	}()
+
+	err = sink.Create()
+	if err != nil {
+		return err
+	}
+
+	err = source.Grab(1)
+	if err != nil {
+		return err
+	}
+
+	err = chain.Run(ctx, source, sink)
+	if errors.Is(err, context.Canceled) {
+		return nil
+	}
+
+	return err
+}
+
+func debounceCmd(args []string) error {
+	var (
+		fs       *flag.FlagSet
+		opts     pipeline.DebounceOptions
+		deferred bool
+		deb      *pipeline.Debouncer
+		ctx      context.Context
+		stop     context.CancelFunc
+		stats    pipeline.DebounceStats
+		key      input.KeyCode
+		err      error
+	)
+
+	fs = flag.NewFlagSet("debounce", flag.ContinueOnError)
+	fs.Usage = usageFn(fs)
+	opts.Keys = make(map[input.KeyCode]time.Duration)
+
+	fs.DurationVar(
+		&opts.Threshold,
+		"threshold",
+		pipeline.DefaultDebounceThreshold,
+		"Time within which repeated changes of a key are bounces",
+	)
+	fs.BoolVar(&deferred, "deferred", false, "Hold changes back until keys settle")
+	fs.Func("key", "Per-key threshold as <KEY>=<duration>, 0 to disable", parseKeyThreshold(opts.Keys))
+
+	err = fs.Parse(args)
+	if err != nil {
+		return errUsage
+	}
+
+	args = fs.Args()
+
+	if len(args) != 1 {
+		fs.Usage()
+
+		return errUsage
+	}
+
+	if deferred {
+		opts.Mode = pipeline.DebounceDeferred
+	}
+
+	deb = pipeline.NewDebouncer(opts)
+
+	ctx, stop = signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	err = run(ctx, args[0], pipeline.Chain{deb})
+	if err != nil {
+		return err
+	}
+
+	stats = deb.Stats()
+	log.Printf("suppressed %d bounces", stats.Suppressed)
+
+	for _, key = range slices.Sorted(maps.Keys(stats.Keys)) {
+		log.Printf("%s: %d", key, stats.Keys[key])
+	}
+
+	return nil
+}
+
 func main() {
+	var err error
+
+	err = errUsage
+
+	switch {
+	case len(os.Args) == 1:
+		usageFn(flag.CommandLine)()
+	case os.Args[1] == "debounce":
+		err = debounceCmd(os.Args[2:])
+	default:
+		usageFn(flag.CommandLine)()
+	}
+
+	if err == nil {
+		return
+	}
+
+	if !errors.Is(err, errUsage) {
+		_, _ = fmt.Fprintf(os.Stderr, "%s: %s\n", os.Args[0], err)
+	}
+
+	os.Exit(1)
 }
@@ -0,0 +1,104 @@
+// Package inputbroker hands out evdev and uinput file descriptors to
+// unprivileged clients according to a policy file.
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/andrieee44/gopkg/linux/broker"
+)
+
+func exit(err error) {
+	_, _ = fmt.Fprintf(os.Stderr, "%s: %s\n", os.Args[0], err)
+
+	os.Exit(1)
+}
+
+func exitIf(err error) {
+	if err != nil {
+		exit(err)
+	}
+}
+
+func loadPolicy(path string) *broker.Policy {
+	var (
+		file   *os.File
+		policy *broker.Policy
+		err    error
+	)
+
+	file, err = os.Open(path)
+	exitIf(err)
+
+	defer func() {
+		exitIf(file.Close())
+	}()
+
+	policy, err = broker.LoadPolicy(file)
+	exitIf(err)
+
+	return policy
+}
+
+func logRequest(cred broker.Credentials, req broker.Request, err error) {
+	if err != nil {
+		log.Printf("pid %d uid %d gid %d: %s %q: %s", cred.PID, cred.UID, cred.GID, req.Kind, req.Path, err)
+
+		return
+	}
+
+	log.Printf("pid %d uid %d gid %d: %s %q: granted", cred.PID, cred.UID, cred.GID, req.Kind, req.Path)
+}
+
+func main() {
+	var (
+		policyPath string
+		socketPath string
+		quiet      bool
+		srv        *broker.Server
+		ctx        context.Context
+		stop       context.CancelFunc
+		err        error
+	)
+
+	flag.Usage = func() {
+		_, err = fmt.Fprintf(flag.CommandLine.Output(), "Usage:\n  %s -policy <policy-file> [-socket <path>] [-quiet]\n", os.Args[0])
+		exitIf(err)
+
+		flag.PrintDefaults()
+	}
+
+	flag.StringVar(&policyPath, "policy", "", "JSON policy file listing the allowed requests")
+	flag.StringVar(&socketPath, "socket", "", "Socket path (default $XDG_RUNTIME_DIR/"+broker.DefaultSocket+")")
+	flag.BoolVar(&quiet, "quiet", false, "Do not log requests")
+	flag.Parse()
+
+	if policyPath == "" {
+		flag.Usage()
+
+		os.Exit(1)
+	}
+
+	if socketPath == "" {
+		socketPath, err = broker.SocketPath()
+		exitIf(err)
+	}
+
+	srv, err = broker.Listen(socketPath, loadPolicy(policyPath))
+	exitIf(err)
+
+	if !quiet {
+		srv.Log = logRequest
+	}
+
+	ctx, stop = signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	exitIf(srv.Serve(ctx))
+}
@@ -0,0 +1,233 @@
+// Package axis turns raw absolute axis values into normalised, filtered
+// values using the parameters of an [input.AbsInfo]. It removes noise
+// with the same fuzz filter the kernel applies, maps values to [-1, 1] or
+// [0, 1], applies flat and radial dead zones, response curves and
+// inversion, and converts values to physical units using the axis
+// resolution.
+//
+// Gamepad sticks, triggers, tablets and touch surfaces all report
+// absolute axes, so every consumer of those devices needs this math.
+package axis
+
+import (
+	"errors"
+	"fmt"
+	"math"
+
+	"github.com/andrieee44/gopkg/linux/uapi/input"
+)
+
+// Range selects the interval an [Axis] is normalised to.
+type Range uint8
+
+const (
+	// Bipolar maps the axis to [-1, 1] with the midpoint of the axis at
+	// zero, as for sticks and wheels.
+	Bipolar Range = iota
+
+	// Unipolar maps the axis to [0, 1] with the minimum at zero, as for
+	// triggers, pedals and pressure.
+	Unipolar
+)
+
+// Curve reshapes a normalised magnitude in [0, 1]. It must map 0 to 0 and
+// 1 to 1 to keep the output range intact.
+type Curve func(magnitude float64) float64
+
+// ErrNoResolution is returned when a physical unit conversion is
+// requested for an axis whose resolution is not reported.
+var ErrNoResolution error = errors.New("axis resolution is not reported")
+
+// Axis processes the values of a single absolute axis. Its exported
+// fields may be changed between calls. The zero value has an empty range
+// and always normalises to zero; create one with [NewAxis].
+type Axis struct {
+	// Info holds the axis parameters reported by the device.
+	Info input.AbsInfo
+
+	// Range selects the normalised output interval.
+	Range Range
+
+	// DeadZone is the fraction of the output range, in [0, 1), around
+	// the rest position that is reported as zero. Values outside the
+	// dead zone are rescaled so the output stays continuous. It defaults
+	// to the Flat value of Info.
+	DeadZone float64
+
+	// Curve, if set, reshapes the magnitude after the dead zone.
+	Curve Curve
+
+	// Invert flips the output direction.
+	Invert bool
+
+	last    int32
+	hasLast bool
+}
+
+// NewAxis returns an [Axis] for info normalised to rng, with its dead
+// zone derived from the Flat parameter of info.
+func NewAxis(info input.AbsInfo, rng Range) *Axis {
+	var axis *Axis
+
+	axis = &Axis{
+		Info:  info,
+		Range: rng,
+	}
+
+	axis.DeadZone = axis.flatFraction()
+
+	return axis
+}
+
+// PowerCurve returns a [Curve] raising the magnitude to exp. Exponents
+// above 1 give finer control near the rest position; below 1 make the
+// axis more sensitive there.
+func PowerCurve(exp float64) Curve {
+	return func(magnitude float64) float64 {
+		return math.Pow(magnitude, exp)
+	}
+}
+
+// Defuzz filters value against the previously accepted value using the
+// Fuzz parameter of the axis, mirroring the kernel's input_defuzz_abs_event:
+// changes within half the fuzz are dropped, and larger changes within
+// twice the fuzz are smoothed. The result becomes the new reference.
+func (axis *Axis) Defuzz(value int32) int32 {
+	var fuzz, old int32
+
+	fuzz = axis.Info.Fuzz
+	old = axis.last
+
+	if fuzz > 0 && axis.hasLast {
+		switch {
+		case value > old-fuzz/2 && value < old+fuzz/2:
+			value = old
+		case value > old-fuzz && value < old+fuzz:
+			value = (old*3 + value) / 4
+		case value > old-fuzz*2 && value < old+fuzz*2:
+			value = (old + value) / 2
+		}
+	}
+
+	axis.last = value
+	axis.hasLast = true
+
+	return value
+}
+
+// Reset forgets the reference value used by [Axis.Defuzz].
+func (axis *Axis) Reset() {
+	axis.last = 0
+	axis.hasLast = false
+}
+
+// Scale maps value linearly onto the axis range and applies inversion,
+// without filtering, dead zones or curves. Values outside the reported
+// minimum and maximum are clamped.
+func (axis *Axis) Scale(value int32) float64 {
+	var minimum, maximum, scaled float64
+
+	minimum = float64(axis.Info.Minimum)
+	maximum = float64(axis.Info.Maximum)
+
+	if maximum <= minimum {
+		return 0
+	}
+
+	scaled = (math.Max(minimum, math.Min(maximum, float64(value))) - minimum) / (maximum - minimum)
+
+	if axis.Range == Bipolar {
+		scaled = scaled*2 - 1
+	}
+
+	if !axis.Invert {
+		return scaled
+	}
+
+	if axis.Range == Bipolar {
+		return -scaled
+	}
+
+	return 1 - scaled
+}
+
+// Normalize runs value through the whole pipeline: [Axis.Defuzz],
+// [Axis.Scale], the dead zone and the curve.
+func (axis *Axis) Normalize(value int32) float64 {
+	var scaled, sign float64
+
+	scaled = axis.Scale(axis.Defuzz(value))
+
+	if axis.Range == Unipolar && axis.Invert {
+		return 1 - shape(1-scaled, axis.DeadZone, axis.Curve)
+	}
+
+	sign = 1
+	if scaled < 0 {
+		sign = -1
+	}
+
+	return sign * shape(math.Abs(scaled), axis.DeadZone, axis.Curve)
+}
+
+// Millimeters converts value to millimetres using the axis resolution,
+// which the kernel reports in units per millimetre for position and
+// contact size axes.
+func (axis *Axis) Millimeters(value int32) (float64, error) {
+	return axis.physical(value, "millimeters")
+}
+
+// Radians converts value to radians using the axis resolution, which the
+// kernel reports in units per radian for the rotational axes
+// [input.ABS_RX], [input.ABS_RY] and [input.ABS_RZ].
+func (axis *Axis) Radians(value int32) (float64, error) {
+	return axis.physical(value, "radians")
+}
+
+// Extent returns the length of the axis range in physical units, such as
+// the width of a touchpad in millimetres.
+func (axis *Axis) Extent() (float64, error) {
+	return axis.physical(axis.Info.Maximum-axis.Info.Minimum, "extent")
+}
+
+func (axis *Axis) physical(value int32, unit string) (float64, error) {
+	if axis.Info.Resolution <= 0 {
+		return 0, fmt.Errorf("failed to convert axis value to %s: %w", unit, ErrNoResolution)
+	}
+
+	return float64(value) / float64(axis.Info.Resolution), nil
+}
+
+func (axis *Axis) flatFraction() float64 {
+	var span float64
+
+	span = float64(axis.Info.Maximum) - float64(axis.Info.Minimum)
+	if span <= 0 || axis.Info.Flat <= 0 {
+		return 0
+	}
+
+	if axis.Range == Bipolar {
+		span /= 2
+	}
+
+	return math.Min(float64(axis.Info.Flat)/span, 0.99)
+}
+
+// shape applies a dead zone and curve to a magnitude in [0, 1].
+func shape(magnitude, deadZone float64, curve Curve) float64 {
+	if magnitude <= deadZone {
+		return 0
+	}
+
+	if deadZone > 0 && deadZone < 1 {
+		magnitude = (magnitude - deadZone) / (1 - deadZone)
+	}
+
+	magnitude = math.Min(1, magnitude)
+
+	if curve != nil {
+		magnitude = curve(magnitude)
+	}
+
+	return magnitude
+}
@@ -0,0 +1,149 @@
+package axis_test
+
+import (
+	"errors"
+	"math"
+	"testing"
+
+	"github.com/andrieee44/gopkg/linux/axis"
+	"github.com/andrieee44/gopkg/linux/uapi/input"
+)
+
+func TestNormalize(t *testing.T) {
+	type table struct {
+		name  string
+		axis  *axis.Axis
+		value int32
+		exp   float64
+	}
+
+	var (
+		stick, trigger, inverted, curved *axis.Axis
+		tests                            []table
+		test                             table
+		got                              float64
+	)
+
+	t.Parallel()
+
+	stick = axis.NewAxis(input.AbsInfo{Minimum: -100, Maximum: 100, Flat: 10}, axis.Bipolar)
+	trigger = axis.NewAxis(input.AbsInfo{Minimum: 0, Maximum: 255}, axis.Unipolar)
+	inverted = axis.NewAxis(input.AbsInfo{Minimum: 0, Maximum: 200}, axis.Bipolar)
+	inverted.Invert = true
+	curved = axis.NewAxis(input.AbsInfo{Minimum: -100, Maximum: 100}, axis.Bipolar)
+	curved.Curve = axis.PowerCurve(2)
+
+	tests = []table{
+		{"centre", stick, 0, 0},
+		{"inside flat", stick, -10, 0},
+		{"outside flat", stick, 55, 0.5},
+		{"minimum", stick, -100, -1},
+		{"clamped", stick, 500, 1},
+		{"trigger rest", trigger, 0, 0},
+		{"trigger full", trigger, 255, 1},
+		{"inverted", inverted, 200, -1},
+		{"curve", curved, -50, -0.25},
+	}
+
+	for _, test = range tests {
+		test.axis.Reset()
+
+		got = test.axis.Normalize(test.value)
+		if math.Abs(got-test.exp) > 1e-9 {
+			t.Errorf("%s: got: %f, exp: %f", test.name, got, test.exp)
+		}
+	}
+}
+
+func TestDefuzz(t *testing.T) {
+	type table struct {
+		value, exp int32
+	}
+
+	var (
+		ax    *axis.Axis
+		tests []table
+		test  table
+		got   int32
+	)
+
+	t.Parallel()
+
+	ax = axis.NewAxis(input.AbsInfo{Minimum: 0, Maximum: 1000, Fuzz: 8}, axis.Unipolar)
+
+	tests = []table{
+		{100, 100},
+		{103, 100},
+		{106, 101},
+		{115, 108},
+		{200, 200},
+	}
+
+	for _, test = range tests {
+		got = ax.Defuzz(test.value)
+		if got != test.exp {
+			t.Errorf("%d: got: %d, exp: %d", test.value, got, test.exp)
+		}
+	}
+}
+
+func TestStick(t *testing.T) {
+	var (
+		stick  *axis.Stick
+		x, y   float64
+		info   input.AbsInfo
+		radius float64
+	)
+
+	t.Parallel()
+
+	info = input.AbsInfo{Minimum: -100, Maximum: 100}
+	stick = axis.NewStick(info, info)
+	stick.DeadZone = 0.2
+
+	x, y = stick.Normalize(10, 10)
+	if x != 0 || y != 0 {
+		t.Errorf("inside dead zone: got: (%f, %f), exp: (0, 0)", x, y)
+	}
+
+	x, y = stick.Normalize(100, 100)
+
+	radius = math.Hypot(x, y)
+	if math.Abs(radius-1) > 1e-9 || math.Abs(x-y) > 1e-9 {
+		t.Errorf("diagonal: got: (%f, %f), exp: unit vector at 45 degrees", x, y)
+	}
+
+	x, y = stick.Normalize(0, -60)
+	if x != 0 || math.Abs(y+0.5) > 1e-9 {
+		t.Errorf("rescaled: got: (%f, %f), exp: (0, -0.5)", x, y)
+	}
+}
+
+func TestPhysical(t *testing.T) {
+	var (
+		ax  *axis.Axis
+		mm  float64
+		err error
+	)
+
+	t.Parallel()
+
+	ax = axis.NewAxis(input.AbsInfo{Minimum: 0, Maximum: 4000, Resolution: 40}, axis.Unipolar)
+
+	mm, err = ax.Millimeters(1000)
+	if err != nil || mm != 25 {
+		t.Errorf("millimeters: got: (%f, %v), exp: (25, nil)", mm, err)
+	}
+
+	mm, err = ax.Extent()
+	if err != nil || mm != 100 {
+		t.Errorf("extent: got: (%f, %v), exp: (100, nil)", mm, err)
+	}
+
+	ax.Info.Resolution = 0
+
+	_, err = ax.Radians(1)
+	if !errors.Is(err, axis.ErrNoResolution) {
+		t.Errorf("radians: got: %v, exp: %v", err, axis.ErrNoResolution)
+	}
+}
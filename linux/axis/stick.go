@@ -0,0 +1,64 @@
+package axis
+
+import (
+	"math"
+
+	"github.com/andrieee44/gopkg/linux/uapi/input"
+)
+
+// Stick processes a pair of bipolar axes, such as [input.ABS_X] and
+// [input.ABS_Y] of a gamepad stick, as a single vector. A radial dead
+// zone treats the stick as one control, so diagonal motion is not
+// clipped the way independent per-axis dead zones clip it.
+type Stick struct {
+	// X is the horizontal axis. Its Range must be [Bipolar]; its own
+	// DeadZone and Curve are ignored.
+	X *Axis
+
+	// Y is the vertical axis. Its Range must be [Bipolar]; its own
+	// DeadZone and Curve are ignored.
+	Y *Axis
+
+	// DeadZone is the radius, in [0, 1), around the centre that is
+	// reported as zero. Magnitudes outside it are rescaled so the output
+	// stays continuous.
+	DeadZone float64
+
+	// Curve, if set, reshapes the magnitude of the vector after the dead
+	// zone.
+	Curve Curve
+}
+
+// NewStick returns a [Stick] for the given axis parameters. The radial
+// dead zone defaults to the larger Flat fraction of the two axes.
+func NewStick(x, y input.AbsInfo) *Stick {
+	var stick *Stick
+
+	stick = &Stick{
+		X: NewAxis(x, Bipolar),
+		Y: NewAxis(y, Bipolar),
+	}
+
+	stick.DeadZone = math.Max(stick.X.DeadZone, stick.Y.DeadZone)
+
+	return stick
+}
+
+// Normalize filters and scales the raw x and y values and applies the
+// radial dead zone and curve. The returned vector has a magnitude of at
+// most 1.
+func (stick *Stick) Normalize(x, y int32) (float64, float64) {
+	var nx, ny, magnitude, shaped float64
+
+	nx = stick.X.Scale(stick.X.Defuzz(x))
+	ny = stick.Y.Scale(stick.Y.Defuzz(y))
+
+	magnitude = math.Hypot(nx, ny)
+	if magnitude == 0 {
+		return 0, 0
+	}
+
+	shaped = shape(math.Min(1, magnitude), stick.DeadZone, stick.Curve)
+
+	return nx / magnitude * shaped, ny / magnitude * shaped
+}
@@ -0,0 +1,245 @@
+// Package broker hands out input device file descriptors to unprivileged
+// processes. A [Server] runs with the rights to open evdev and uinput
+// devices, such as root or membership of the input group, and listens on
+// a unix socket. Clients ask it for a device with [OpenEvdev] or
+// [OpenUinput]; the server checks the request against a [Policy] using
+// the client's kernel-verified credentials, opens the device and passes
+// the open file back over the socket with SCM_RIGHTS. The client never
+// needs the rights to open the device itself, and gets no access to
+// devices the policy does not name.
+package broker
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"time"
+
+	"github.com/andrieee44/gopkg/linux/evdev"
+	"github.com/andrieee44/gopkg/linux/uapi/input"
+	"github.com/andrieee44/gopkg/linux/uinput"
+	"github.com/andrieee44/gopkg/linux/xdg"
+	"golang.org/x/sys/unix"
+)
+
+// DefaultSocket is the path of the broker socket relative to the XDG
+// runtime directory.
+const DefaultSocket string = "gopkg/inputbroker.sock"
+
+// Timeout bounds a single request, on both sides of the connection.
+const Timeout time.Duration = 5 * time.Second
+
+var (
+	// ErrDenied is returned when the policy does not allow a request.
+	ErrDenied error = errors.New("request denied by broker policy")
+
+	// ErrInvalidRequest is returned for a request the broker cannot
+	// serve, such as a path outside /dev/input.
+	ErrInvalidRequest error = errors.New("invalid broker request")
+
+	// ErrBrokerFailed is returned when the broker could not open an
+	// allowed device.
+	ErrBrokerFailed error = errors.New("broker failed to open device")
+
+	// ErrNoFd is returned when the broker replied without a file
+	// descriptor.
+	ErrNoFd error = errors.New("broker reply carries no file descriptor")
+)
+
+// Request asks the broker for a device.
+type Request struct {
+	// Kind is the kind of device.
+	Kind Kind `json:"kind"`
+
+	// Path is the evdev device node. It is ignored for uinput requests.
+	Path string `json:"path,omitempty"`
+
+	// Write requests an evdev device opened for writing.
+	Write bool `json:"write,omitempty"`
+}
+
+type response struct {
+	Error  string `json:"error,omitempty"`
+	Denied bool   `json:"denied,omitempty"`
+}
+
+// SocketPath returns the default broker socket path, [DefaultSocket]
+// under the XDG runtime directory, creating missing directories.
+func SocketPath() (string, error) {
+	return xdg.RuntimePath(DefaultSocket)
+}
+
+// Open sends req to the broker listening at socketPath and returns the
+// device file it passes back. The caller owns the file.
+func Open(socketPath string, req Request) (*os.File, error) {
+	var (
+		conn     *net.UnixConn
+		data     []byte
+		buf, oob []byte
+		n, oobn  int
+		resp     response
+		file     *os.File
+		err      error
+	)
+
+	conn, err = net.DialUnix("unix", nil, &net.UnixAddr{Name: socketPath, Net: "unix"})
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to input broker: %w", err)
+	}
+
+	defer func() {
+		_ = conn.Close()
+	}()
+
+	err = conn.SetDeadline(time.Now().Add(Timeout))
+	if err != nil {
+		return nil, fmt.Errorf("failed to set input broker deadline: %w", err)
+	}
+
+	data, err = json.Marshal(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode input broker request: %w", err)
+	}
+
+	_, err = conn.Write(append(data, '\n'))
+	if err != nil {
+		return nil, fmt.Errorf("failed to send input broker request: %w", err)
+	}
+
+	buf = make([]byte, 4096)
+	oob = make([]byte, unix.CmsgSpace(4))
+
+	n, oobn, _, _, err = conn.ReadMsgUnix(buf, oob)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read input broker reply: %w", err)
+	}
+
+	file, err = receiveFile(oob[:oobn], req)
+	if err != nil {
+		return nil, err
+	}
+
+	err = json.Unmarshal(buf[:n], &resp)
+	if err != nil {
+		closeFile(file)
+
+		return nil, fmt.Errorf("failed to decode input broker reply: %w", err)
+	}
+
+	switch {
+	case resp.Denied:
+		closeFile(file)
+
+		return nil, fmt.Errorf("%s: %w", req.target(), ErrDenied)
+	case resp.Error != "":
+		closeFile(file)
+
+		return nil, fmt.Errorf("%s: %s: %w", req.target(), resp.Error, ErrBrokerFailed)
+	case file == nil:
+		return nil, fmt.Errorf("%s: %w", req.target(), ErrNoFd)
+	}
+
+	return file, nil
+}
+
+// OpenEvdev asks the broker listening at socketPath for the evdev device
+// at devPath, opened for writing if write is set.
+func OpenEvdev(socketPath, devPath string, write bool) (*evdev.Device, error) {
+	var (
+		file *os.File
+		dev  *evdev.Device
+		err  error
+	)
+
+	file, err = Open(socketPath, Request{Kind: KindEvdev, Path: devPath, Write: write})
+	if err != nil {
+		return nil, err
+	}
+
+	dev, err = evdev.NewDeviceFromFile(file)
+	if err != nil {
+		closeFile(file)
+
+		return nil, err
+	}
+
+	return dev, nil
+}
+
+// OpenUinput asks the broker listening at socketPath for the uinput
+// control device and returns a [uinput.Device] with the given identifier
+// and name.
+func OpenUinput(socketPath string, id input.ID, name string) (*uinput.Device, error) {
+	var (
+		file *os.File
+		dev  *uinput.Device
+		err  error
+	)
+
+	file, err = Open(socketPath, Request{Kind: KindUinput})
+	if err != nil {
+		return nil, err
+	}
+
+	dev, err = uinput.NewDeviceFromFile(file, id, name)
+	if err != nil {
+		closeFile(file)
+
+		return nil, err
+	}
+
+	return dev, nil
+}
+
+func receiveFile(oob []byte, req Request) (*os.File, error) {
+	var (
+		msgs     []unix.SocketControlMessage
+		msgIdx   int
+		fds, all []int
+		fd       int
+		err      error
+	)
+
+	if len(oob) == 0 {
+		return nil, nil
+	}
+
+	msgs, err = unix.ParseSocketControlMessage(oob)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse input broker reply: %w", ErrNoFd)
+	}
+
+	for msgIdx = range msgs {
+		fds, err = unix.ParseUnixRights(&msgs[msgIdx])
+		if err == nil {
+			all = append(all, fds...)
+		}
+	}
+
+	if len(all) != 1 {
+		for _, fd = range all {
+			_ = unix.Close(fd)
+		}
+
+		return nil, fmt.Errorf("failed to parse input broker reply: %w", ErrNoFd)
+	}
+
+	return os.NewFile(uintptr(all[0]), req.target()), nil
+}
+
+// target names the requested device in errors and file names.
+func (req Request) target() string {
+	if req.Kind == KindUinput {
+		return uinput.DefaultPath
+	}
+
+	return req.Path
+}
+
+func closeFile(file *os.File) {
+	if file != nil {
+		_ = file.Close()
+	}
+}
@@ -0,0 +1,208 @@
+package broker_test
+
+import (
+	"context"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"testing"
+
+	"github.com/andrieee44/gopkg/linux/broker"
+	"github.com/andrieee44/gopkg/linux/uapi/input"
+)
+
+func TestPolicyAllow(t *testing.T) {
+	type table struct {
+		name  string
+		cred  broker.Credentials
+		kind  broker.Kind
+		info  broker.DeviceInfo
+		write bool
+		exp   bool
+	}
+
+	var (
+		policy *broker.Policy
+		tests  []table
+		test   table
+		wacom  broker.DeviceInfo
+		err    error
+	)
+
+	t.Parallel()
+
+	policy, err = broker.LoadPolicy(strings.NewReader(`{
+		"rules": [
+			{"uids": [1000], "kind": "evdev", "name": "Wacom *", "vendor": 1386},
+			{"gids": [100], "kind": "evdev", "path": "/dev/input/event1?", "write": true},
+			{"uids": [1001], "kind": "uinput"}
+		]
+	}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	wacom = broker.DeviceInfo{
+		Path: "/dev/input/event5",
+		Name: "Wacom Intuos Pen",
+		ID:   input.ID{Vendor: 0x056a},
+	}
+
+	tests = []table{
+		{"name and vendor", broker.Credentials{UID: 1000}, broker.KindEvdev, wacom, false, true},
+		{"read only rule", broker.Credentials{UID: 1000}, broker.KindEvdev, wacom, true, false},
+		{"other user", broker.Credentials{UID: 1002}, broker.KindEvdev, wacom, false, false},
+		{
+			"path and group",
+			broker.Credentials{UID: 1002, GID: 100},
+			broker.KindEvdev,
+			broker.DeviceInfo{Path: "/dev/input/event12"},
+			true,
+			true,
+		},
+		{"uinput", broker.Credentials{UID: 1001}, broker.KindUinput, broker.DeviceInfo{}, true, true},
+		{"uinput other user", broker.Credentials{UID: 1000}, broker.KindUinput, broker.DeviceInfo{}, true, false},
+	}
+
+	for _, test = range tests {
+		if policy.Allow(test.cred, test.kind, test.info, test.write) != test.exp {
+			t.Errorf("%s: got: %t, exp: %t", test.name, !test.exp, test.exp)
+		}
+	}
+}
+
+func TestLoadPolicyInvalid(t *testing.T) {
+	var (
+		policies []string
+		policy   string
+		err      error
+	)
+
+	t.Parallel()
+
+	policies = []string{
+		`{"rules": [{"kind": "joystick"}]}`,
+		`{"rules": [{"kind": "evdev", "name": "["}]}`,
+		`{"rules": [{"kind": "evdev", "color": "red"}]}`,
+		`not json`,
+	}
+
+	for _, policy = range policies {
+		_, err = broker.LoadPolicy(strings.NewReader(policy))
+		if !errors.Is(err, broker.ErrInvalidPolicy) {
+			t.Errorf("%s: got: %v, exp: %v", policy, err, broker.ErrInvalidPolicy)
+		}
+	}
+}
+
+func startServer(t *testing.T, socket, uinputPath string, policy *broker.Policy) {
+	var (
+		srv    *broker.Server
+		ctx    context.Context
+		cancel context.CancelFunc
+		done   chan error
+		err    error
+	)
+
+	t.Helper()
+
+	srv, err = broker.Listen(socket, policy)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	srv.UinputPath = uinputPath
+	ctx, cancel = context.WithCancel(t.Context())
+	done = make(chan error, 1)
+
+	go func() {
+		done <- srv.Serve(ctx)
+	}()
+
+	t.Cleanup(func() {
+		cancel()
+
+		err = <-done
+		if err != nil {
+			t.Errorf("serve: got: %v, exp: nil", err)
+		}
+	})
+}
+
+func TestServer(t *testing.T) {
+	var (
+		dir, allowed, denied, fake string
+		file                       *os.File
+		data                       []byte
+		err                        error
+	)
+
+	t.Parallel()
+
+	dir = t.TempDir()
+	allowed = filepath.Join(dir, "allowed.sock")
+	denied = filepath.Join(dir, "denied.sock")
+	fake = filepath.Join(dir, "uinput")
+
+	err = os.WriteFile(fake, []byte("fake uinput"), 0o600)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	startServer(t, allowed, fake, &broker.Policy{
+		Rules: []broker.Rule{{UIDs: []uint32{uint32(os.Getuid())}, Kind: broker.KindUinput}},
+	})
+	startServer(t, denied, fake, &broker.Policy{})
+
+	file, err = broker.Open(allowed, broker.Request{Kind: broker.KindUinput})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	data, err = io.ReadAll(file)
+	if err != nil || string(data) != "fake uinput" {
+		t.Errorf("passed file: got: (%q, %v), exp: fake uinput", data, err)
+	}
+
+	_ = file.Close()
+
+	_, err = broker.Open(allowed, broker.Request{Kind: broker.KindEvdev, Path: dir})
+	if !errors.Is(err, broker.ErrBrokerFailed) {
+		t.Errorf("invalid path: got: %v, exp: %v", err, broker.ErrBrokerFailed)
+	}
+
+	_, err = broker.Open(denied, broker.Request{Kind: broker.KindUinput})
+	if !errors.Is(err, broker.ErrDenied) {
+		t.Errorf("denied: got: %v, exp: %v", err, broker.ErrDenied)
+	}
+}
+
+func TestListenKeepsFile(t *testing.T) {
+	var (
+		path string
+		data []byte
+		err  error
+	)
+
+	t.Parallel()
+
+	path = filepath.Join(t.TempDir(), "not-a-socket")
+
+	err = os.WriteFile(path, []byte("keep"), 0o600)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = broker.Listen(path, &broker.Policy{})
+	if !errors.Is(err, syscall.ENOTSOCK) {
+		t.Errorf("got: %v, exp: %v", err, syscall.ENOTSOCK)
+	}
+
+	data, err = os.ReadFile(path)
+	if err != nil || string(data) != "keep" {
+		t.Errorf("file: got: %q, %v, exp: %q", data, err, "keep")
+	}
+}
@@ -0,0 +1,204 @@
+package broker
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"slices"
+
+	"github.com/andrieee44/gopkg/linux/uapi/input"
+)
+
+// Kind is the kind of device a [Request] asks for.
+type Kind string
+
+const (
+	// KindEvdev requests an evdev device node under /dev/input.
+	KindEvdev Kind = "evdev"
+
+	// KindUinput requests the uinput control device for creating virtual
+	// devices.
+	KindUinput Kind = "uinput"
+)
+
+// ErrInvalidPolicy is returned by [LoadPolicy] for a malformed policy.
+var ErrInvalidPolicy error = errors.New("invalid broker policy")
+
+// Credentials identifies the process at the other end of a connection,
+// as reported by the kernel with SO_PEERCRED.
+type Credentials struct {
+	// PID is the process ID.
+	PID int32
+
+	// UID is the user ID.
+	UID uint32
+
+	// GID is the primary group ID.
+	GID uint32
+}
+
+// DeviceInfo describes the device a [Request] resolved to. It is empty
+// apart from Path for uinput requests.
+type DeviceInfo struct {
+	// Path is the resolved device node path.
+	Path string
+
+	// Name is the device name.
+	Name string
+
+	// ID is the device identifier.
+	ID input.ID
+}
+
+// Rule grants access to matching devices for matching clients. Empty
+// fields match anything.
+type Rule struct {
+	// UIDs lists the user IDs the rule applies to.
+	UIDs []uint32 `json:"uids,omitempty"`
+
+	// GIDs lists the primary group IDs the rule applies to.
+	GIDs []uint32 `json:"gids,omitempty"`
+
+	// Kind is the kind of device the rule grants. It is required.
+	Kind Kind `json:"kind"`
+
+	// Path is a [path.Match] pattern for the resolved device node.
+	Path string `json:"path,omitempty"`
+
+	// Name is a [path.Match] pattern for the device name.
+	Name string `json:"name,omitempty"`
+
+	// Bustype matches the bus type of the device's [input.ID].
+	Bustype input.BusCode `json:"bustype,omitempty"`
+
+	// Vendor matches the vendor of the device's [input.ID].
+	Vendor uint16 `json:"vendor,omitempty"`
+
+	// Product matches the product of the device's [input.ID].
+	Product uint16 `json:"product,omitempty"`
+
+	// Write allows opening evdev devices for writing, which is needed to
+	// grab them, set LEDs or play force feedback. Without it only
+	// read-only access is granted.
+	Write bool `json:"write,omitempty"`
+}
+
+// Policy is an ordered list of rules. A request is allowed if any rule
+// grants it; everything else is denied. It is usually loaded from a JSON
+// file of the form
+//
+//	{
+//		"rules": [
+//			{"uids": [1000], "kind": "evdev", "name": "Wacom *"},
+//			{"gids": [100], "kind": "uinput"}
+//		]
+//	}
+type Policy struct {
+	// Rules lists the granting rules.
+	Rules []Rule `json:"rules"`
+}
+
+// LoadPolicy decodes a JSON policy from reader and validates it.
+func LoadPolicy(reader io.Reader) (*Policy, error) {
+	var (
+		decoder *json.Decoder
+		policy  *Policy
+		rule    Rule
+		idx     int
+		err     error
+	)
+
+	policy = new(Policy)
+	decoder = json.NewDecoder(reader)
+	decoder.DisallowUnknownFields()
+
+	err = decoder.Decode(policy)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode broker policy: %w: %w", ErrInvalidPolicy, err)
+	}
+
+	for idx, rule = range policy.Rules {
+		err = rule.validate()
+		if err != nil {
+			return nil, fmt.Errorf("rule %d: %w", idx, err)
+		}
+	}
+
+	return policy, nil
+}
+
+// Allow reports whether the policy lets a client with cred open the
+// device described by info as kind, for writing if write is set.
+func (policy *Policy) Allow(cred Credentials, kind Kind, info DeviceInfo, write bool) bool {
+	var rule Rule
+
+	for _, rule = range policy.Rules {
+		if rule.matches(cred, kind, info, write) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (rule Rule) validate() error {
+	var err error
+
+	if rule.Kind != KindEvdev && rule.Kind != KindUinput {
+		return fmt.Errorf("kind %q: %w", rule.Kind, ErrInvalidPolicy)
+	}
+
+	_, err = path.Match(rule.Path, "")
+	if err != nil {
+		return fmt.Errorf("path %q: %w: %w", rule.Path, ErrInvalidPolicy, err)
+	}
+
+	_, err = path.Match(rule.Name, "")
+	if err != nil {
+		return fmt.Errorf("name %q: %w: %w", rule.Name, ErrInvalidPolicy, err)
+	}
+
+	return nil
+}
+
+func (rule Rule) matches(cred Credentials, kind Kind, info DeviceInfo, write bool) bool {
+	if rule.Kind != kind {
+		return false
+	}
+
+	if len(rule.UIDs) != 0 && !slices.Contains(rule.UIDs, cred.UID) {
+		return false
+	}
+
+	if len(rule.GIDs) != 0 && !slices.Contains(rule.GIDs, cred.GID) {
+		return false
+	}
+
+	if kind == KindUinput {
+		return true
+	}
+
+	if write && !rule.Write {
+		return false
+	}
+
+	return glob(rule.Path, info.Path) &&
+		glob(rule.Name, info.Name) &&
+		(rule.Bustype == 0 || rule.Bustype == info.ID.Bustype) &&
+		(rule.Vendor == 0 || rule.Vendor == info.ID.Vendor) &&
+		(rule.Product == 0 || rule.Product == info.ID.Product)
+}
+
+func glob(pattern, name string) bool {
+	var matched bool
+
+	if pattern == "" {
+		return true
+	}
+
+	matched, _ = path.Match(pattern, name)
+
+	return matched
+}
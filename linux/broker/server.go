@@ -0,0 +1,341 @@
+package broker
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/andrieee44/gopkg/linux/evdev"
+	"github.com/andrieee44/gopkg/linux/uinput"
+	"golang.org/x/sys/unix"
+)
+
+// Server answers device requests on a unix socket according to a
+// [Policy].
+type Server struct {
+	// Policy decides which requests are allowed. It must not be changed
+	// while the server is running.
+	Policy *Policy
+
+	// UinputPath is the uinput control device handed out for uinput
+	// requests. It defaults to [uinput.DefaultPath].
+	UinputPath string
+
+	// Log, if set, is called after each request with the client's
+	// credentials, the request and the resulting error, which is nil for
+	// a granted request.
+	Log func(cred Credentials, req Request, err error)
+
+	listener *net.UnixListener
+}
+
+// Listen creates a [Server] listening on socketPath, replacing a stale
+// socket left by a previous run. Any other file at socketPath is left in
+// place and reported as an error. The socket is made connectable by every
+// user; access is decided by the policy using the credentials of each
+// client, and by the permissions of the directory holding the socket.
+func Listen(socketPath string, policy *Policy) (*Server, error) {
+	const anyone os.FileMode = 0o666
+
+	var (
+		srv *Server
+		err error
+	)
+
+	err = removeStaleSocket(socketPath)
+	if err != nil {
+		return nil, err
+	}
+
+	srv = &Server{
+		Policy:     policy,
+		UinputPath: uinput.DefaultPath,
+	}
+
+	srv.listener, err = net.ListenUnix("unix", &net.UnixAddr{Name: socketPath, Net: "unix"})
+	if err != nil {
+		return nil, fmt.Errorf("failed to listen on input broker socket: %w", err)
+	}
+
+	err = os.Chmod(socketPath, anyone)
+	if err != nil {
+		_ = srv.listener.Close()
+
+		return nil, fmt.Errorf("failed to set input broker socket permissions: %w", err)
+	}
+
+	return srv, nil
+}
+
+// removeStaleSocket removes the socket at path, if any. It refuses to
+// remove anything but a socket, so a mistyped path cannot delete a file.
+func removeStaleSocket(path string) error {
+	var (
+		info os.FileInfo
+		err  error
+	)
+
+	info, err = os.Lstat(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+
+	if err != nil {
+		return fmt.Errorf("failed to check stale input broker socket: %w", err)
+	}
+
+	if info.Mode()&os.ModeSocket == 0 {
+		return fmt.Errorf(
+			"failed to remove stale input broker socket: %s: %w",
+			path,
+			syscall.ENOTSOCK,
+		)
+	}
+
+	err = os.Remove(path)
+	if err != nil {
+		return fmt.Errorf("failed to remove stale input broker socket: %w", err)
+	}
+
+	return nil
+}
+
+// Addr returns the socket path the server listens on.
+func (srv *Server) Addr() string {
+	return srv.listener.Addr().String()
+}
+
+// Serve accepts and answers requests until ctx is done or the listener
+// fails. It closes the listener, removing the socket, before returning.
+// Requests are answered concurrently.
+func (srv *Server) Serve(ctx context.Context) error {
+	var (
+		conn *net.UnixConn
+		stop func() bool
+		err  error
+	)
+
+	stop = context.AfterFunc(ctx, func() {
+		_ = srv.listener.Close()
+	})
+
+	defer stop()
+
+	for {
+		conn, err = srv.listener.AcceptUnix()
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+
+			_ = srv.listener.Close()
+
+			return fmt.Errorf("failed to accept input broker connection: %w", err)
+		}
+
+		go srv.handle(conn)
+	}
+}
+
+// Close stops the server, removing its socket.
+func (srv *Server) Close() error {
+	var err error
+
+	err = srv.listener.Close()
+	if err != nil {
+		return fmt.Errorf("failed to close input broker: %w", err)
+	}
+
+	return nil
+}
+
+func (srv *Server) handle(conn *net.UnixConn) {
+	var (
+		cred    Credentials
+		req     Request
+		file    *os.File
+		resp    response
+		oob     []byte
+		data    []byte
+		err     error
+		sendErr error
+	)
+
+	defer func() {
+		_ = conn.Close()
+	}()
+
+	cred, req, file, err = srv.receive(conn)
+	if err != nil {
+		resp.Error = err.Error()
+		resp.Denied = errors.Is(err, ErrDenied)
+	}
+
+	if file != nil {
+		oob = unix.UnixRights(int(file.Fd()))
+
+		defer func() {
+			_ = file.Close()
+		}()
+	}
+
+	data, sendErr = json.Marshal(resp)
+	if sendErr == nil {
+		_, _, sendErr = conn.WriteMsgUnix(data, oob, nil)
+	}
+
+	if sendErr != nil {
+		err = errors.Join(err, fmt.Errorf("failed to send input broker reply: %w", sendErr))
+	}
+
+	if srv.Log != nil {
+		srv.Log(cred, req, err)
+	}
+}
+
+func (srv *Server) receive(conn *net.UnixConn) (Credentials, Request, *os.File, error) {
+	var (
+		cred Credentials
+		req  Request
+		file *os.File
+		err  error
+	)
+
+	err = conn.SetDeadline(time.Now().Add(Timeout))
+	if err != nil {
+		return cred, req, nil, fmt.Errorf("failed to set input broker deadline: %w", err)
+	}
+
+	cred, err = peerCredentials(conn)
+	if err != nil {
+		return cred, req, nil, err
+	}
+
+	err = json.NewDecoder(conn).Decode(&req)
+	if err != nil {
+		return cred, req, nil, fmt.Errorf("failed to decode input broker request: %w: %w", ErrInvalidRequest, err)
+	}
+
+	file, err = srv.open(cred, req)
+
+	return cred, req, file, err
+}
+
+func (srv *Server) open(cred Credentials, req Request) (*os.File, error) {
+	var (
+		info DeviceInfo
+		file *os.File
+		err  error
+	)
+
+	switch req.Kind {
+	case KindUinput:
+		info.Path = srv.UinputPath
+		if !srv.Policy.Allow(cred, KindUinput, info, true) {
+			return nil, fmt.Errorf("uid %d: %w", cred.UID, ErrDenied)
+		}
+
+		file, err = os.OpenFile(srv.UinputPath, os.O_RDWR, 0)
+		if err != nil {
+			return nil, fmt.Errorf("failed to open uinput device: %w", err)
+		}
+
+		return file, nil
+	case KindEvdev:
+		return srv.openEvdev(cred, req)
+	default:
+		return nil, fmt.Errorf("kind %q: %w", req.Kind, ErrInvalidRequest)
+	}
+}
+
+func (srv *Server) openEvdev(cred Credentials, req Request) (*os.File, error) {
+	var (
+		info DeviceInfo
+		flag int
+		file *os.File
+		dev  *evdev.Device
+		keep bool
+		err  error
+	)
+
+	info.Path, err = filepath.EvalSymlinks(req.Path)
+	if err != nil || !strings.HasPrefix(info.Path, "/dev/input/event") {
+		return nil, fmt.Errorf("path %q: %w", req.Path, ErrInvalidRequest)
+	}
+
+	flag = os.O_RDONLY
+	if req.Write {
+		flag = os.O_RDWR
+	}
+
+	file, err = os.OpenFile(info.Path, flag, 0)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open evdev device: %w", err)
+	}
+
+	defer func() {
+		if !keep {
+			_ = file.Close()
+		}
+	}()
+
+	dev, err = evdev.NewDeviceFromFile(file)
+	if err != nil {
+		return nil, err
+	}
+
+	info.ID, err = dev.ID()
+	if err == nil {
+		info.Name, err = dev.Name(256)
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	if !srv.Policy.Allow(cred, KindEvdev, info, req.Write) {
+		return nil, fmt.Errorf("uid %d: %s: %w", cred.UID, info.Path, ErrDenied)
+	}
+
+	keep = true
+
+	return file, nil
+}
+
+func peerCredentials(conn *net.UnixConn) (Credentials, error) {
+	var (
+		rawConn syscall.RawConn
+		ucred   *unix.Ucred
+		err     error
+		credErr error
+	)
+
+	rawConn, err = conn.SyscallConn()
+	if err != nil {
+		return Credentials{}, fmt.Errorf("failed to get input broker peer credentials: %w", err)
+	}
+
+	err = rawConn.Control(func(fd uintptr) {
+		ucred, credErr = unix.GetsockoptUcred(int(fd), unix.SOL_SOCKET, unix.SO_PEERCRED)
+	})
+	if err == nil {
+		err = credErr
+	}
+
+	if err != nil {
+		return Credentials{}, fmt.Errorf("failed to get input broker peer credentials: %w", err)
+	}
+
+	return Credentials{
+		PID: ucred.Pid,
+		UID: ucred.Uid,
+		GID: ucred.Gid,
+	}, nil
+}
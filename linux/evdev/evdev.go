@@ -2,9 +2,14 @@
 // device) subsystem. It lets you open input devices (keyboards, mice,
 // gamepads, etc.), read raw input events, and interpret them as high-level
 // actions.
+//
+// Failed device operations return an [inputerr.OpError], which can be
+// matched against the [inputerr] sentinels such as [inputerr.ErrPermission]
+// or [inputerr.ErrDeviceGone] with [errors.Is].
 package evdev
 
 import (
+	"bytes"
 	"encoding/binary"
 	"errors"
 	"fmt"
@@ -12,8 +17,10 @@ import (
 	"math"
 	"os"
 	"path/filepath"
+	"slices"
 	"unsafe"
 
+	"github.com/andrieee44/gopkg/linux/inputerr"
 	"github.com/andrieee44/gopkg/linux/internal/inputwrap"
 	"github.com/andrieee44/gopkg/linux/internal/ioctlwrap"
 	"github.com/andrieee44/gopkg/linux/uapi/input"
@@ -24,6 +31,10 @@ import (
 // does not correspond to any multitouch axis or slot index.
 var ErrNotMultiTouch error = errors.New("is not a multitouch code")
 
+// ErrUnsupportedCode is returned when writing an event whose code the
+// device does not report as supported.
+var ErrUnsupportedCode error = errors.New("code is not supported by the device")
+
 // Device represents an evdev device.
 // It wraps the opened /dev/input/eventN file.
 type Device struct {
@@ -33,26 +44,75 @@ type Device struct {
 }
 
 // NewDevice opens the evdev device at the given path and returns a [Device].
-// The device file is opened in read-write mode. The caller is responsible
-// for releasing resources by calling [Device.Close] when the device is no
-// longer needed.
+// The path may be any identifier accepted by [ResolvePath], such as a
+// by-id link or a sysfs key, so configurations can name a device in a way
+// that survives reboots. The device file is opened in read-write mode.
+// The caller is responsible for releasing resources by calling
+// [Device.Close] when the device is no longer needed.
 func NewDevice(path string) (*Device, error) {
+	return OpenDevice(path, os.O_RDWR)
+}
+
+// OpenDevice opens the evdev device at the given path with the given
+// [os.OpenFile] flags and returns a [Device]. Opening with [os.O_RDONLY]
+// is enough to read events and query the device; writing events, such as
+// with [Device.SetLED] or [Device.PlayFF], needs [os.O_RDWR]. The flag may
+// also include [os.O_NONBLOCK]. Like [NewDevice], it accepts any
+// identifier accepted by [ResolvePath].
+func OpenDevice(path string, flag int) (*Device, error) {
 	var (
-		device *Device
-		file   *os.File
-		err    error
+		file *os.File
+		err  error
 	)
 
-	file, err = os.OpenFile(filepath.Clean(path), os.O_RDWR, 0)
+	path, err = ResolvePath(path)
+	if err != nil {
+		return nil, err
+	}
+
+	file, err = os.OpenFile(filepath.Clean(path), flag, 0)
+	if err != nil {
+		return nil, inputerr.Wrap("failed to open evdev device", path, err)
+	}
+
+	return &Device{
+		file: file,
+	}, nil
+}
+
+// NewDeviceFromFile returns a [Device] for an already open evdev device
+// file, such as one inherited from a privileged parent or received over a
+// socket. The [Device] takes ownership of file and closes it in
+// [Device.Close]. NewDeviceFromFile checks that file is an evdev device
+// and returns an error classified as [inputerr.ErrNotEvdev] otherwise;
+// file is left open in that case.
+func NewDeviceFromFile(file *os.File) (*Device, error) {
+	var err error
+
+	err = checkFd(file.Fd(), file.Name())
 	if err != nil {
-		return nil, fmt.Errorf("failed to open evdev device: %w", err)
+		return nil, err
 	}
 
-	device = &Device{
+	return &Device{
 		file: file,
+	}, nil
+}
+
+// NewDeviceFromFd is like [NewDeviceFromFile] for a raw file descriptor.
+// The name is used as the device's [Device.Filename] and in errors. The fd
+// is only taken over if it is an evdev device.
+func NewDeviceFromFd(fd uintptr, name string) (*Device, error) {
+	var err error
+
+	err = checkFd(fd, name)
+	if err != nil {
+		return nil, err
 	}
 
-	return device, nil
+	return &Device{
+		file: os.NewFile(fd, name),
+	}, nil
 }
 
 // Devices attempts to open all discovered input devices and returns
@@ -701,19 +761,124 @@ func (dev *Device) PlayFF(id, value int32) error {
 		Value: value,
 	})
 	if err != nil {
-		return fmt.Errorf("%s: failed to play force feedback: %w", dev.Filename(), err)
+		return inputerr.Wrap("failed to play force feedback", dev.Filename(), err)
+	}
+
+	return nil
+}
+
+// WriteEvents writes events to the evdev device in a single write, so
+// the kernel handles them as one batch. The device passes them to its
+// driver, which is how LEDs, sounds and force feedback are controlled;
+// callers are responsible for ending frames with [input.SYN_REPORT] where
+// the driver expects it.
+func (dev *Device) WriteEvents(events []input.Event) error {
+	var (
+		buf bytes.Buffer
+		err error
+	)
+
+	err = binary.Write(&buf, binary.NativeEndian, events)
+	if err != nil {
+		return fmt.Errorf("%s: failed to encode events: %w", dev.Filename(), err)
+	}
+
+	_, err = dev.file.Write(buf.Bytes())
+	if err != nil {
+		return inputerr.Wrap("failed to write events", dev.Filename(), err)
 	}
 
 	return nil
 }
 
+// SetLED turns the given LED, such as [input.LED_CAPSL], on or off. It
+// returns [ErrUnsupportedCode] if the device does not report the LED in
+// [Device.LEDs].
+func (dev *Device) SetLED(led input.LEDCode, on bool) error {
+	var (
+		leds  []input.LEDCode
+		value int32
+		err   error
+	)
+
+	leds, err = dev.LEDs()
+	if err != nil {
+		return fmt.Errorf("failed to set LED %s: %w", led, err)
+	}
+
+	if !slices.Contains(leds, led) {
+		return fmt.Errorf(
+			"%s: LED %s: failed to set LED: %w",
+			dev.Filename(),
+			led,
+			ErrUnsupportedCode,
+		)
+	}
+
+	if on {
+		value = 1
+	}
+
+	return dev.WriteEvents([]input.Event{
+		{Type: input.EV_LED, Code: led.Value(), Value: value},
+		{Type: input.EV_SYN, Code: input.SYN_REPORT.Value()},
+	})
+}
+
+// Beep starts or stops the device's bell with [input.SND_BELL]. It
+// returns [ErrUnsupportedCode] if the device does not report the sound in
+// [Device.Sounds].
+func (dev *Device) Beep(on bool) error {
+	var value int32
+
+	if on {
+		value = 1
+	}
+
+	return dev.sound(input.SND_BELL, value)
+}
+
+// Tone plays a tone of the given frequency in hertz with
+// [input.SND_TONE], or stops it if hz is zero. It returns
+// [ErrUnsupportedCode] if the device does not report the sound in
+// [Device.Sounds].
+func (dev *Device) Tone(hz int32) error {
+	return dev.sound(input.SND_TONE, hz)
+}
+
+func (dev *Device) sound(snd input.SoundCode, value int32) error {
+	var (
+		sounds []input.SoundCode
+		err    error
+	)
+
+	sounds, err = dev.Sounds()
+	if err != nil {
+		return fmt.Errorf("failed to play sound %s: %w", snd, err)
+	}
+
+	if !slices.Contains(sounds, snd) {
+		return fmt.Errorf(
+			"%s: sound %s: failed to play sound: %w",
+			dev.Filename(),
+			snd,
+			ErrUnsupportedCode,
+		)
+	}
+
+	return dev.WriteEvents([]input.Event{
+		{Type: input.EV_SND, Code: snd.Value(), Value: value},
+		{Type: input.EV_SYN, Code: input.SYN_REPORT.Value()},
+	})
+}
+
 // Close closes the evdev device by closing its underlying file handle.
 func (dev *Device) Close() error {
 	var err error
 
 	err = dev.file.Close()
 	if err != nil {
-		return fmt.Errorf("failed to close event device: %w", err)
+		return inputerr.Wrap("failed to close event device", dev.Filename(), err)
 	}
 
 	return nil
@@ -732,7 +897,7 @@ func (dev *Device) serve() {
 				break
 			}
 
-			dev.errChan <- err
+			dev.errChan <- inputerr.Wrap("failed to read events", dev.Filename(), err)
 
 			break
 		}
@@ -743,3 +908,14 @@ func (dev *Device) serve() {
 	close(dev.eventsChan)
 	close(dev.errChan)
 }
+
+func checkFd(fd uintptr, name string) error {
+	var err error
+
+	_, err = ioctl.GetAny(fd, input.EVIOCGVERSION, new(int32))
+	if err != nil {
+		return inputerr.Wrap("failed to adopt evdev device", name, err)
+	}
+
+	return nil
+}
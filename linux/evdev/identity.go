@@ -0,0 +1,253 @@
+package evdev
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"syscall"
+
+	"github.com/andrieee44/gopkg/linux/inputerr"
+	"golang.org/x/sys/unix"
+)
+
+const (
+	// ByIDDir is the directory where udev links input devices by their
+	// vendor, model and serial number.
+	ByIDDir string = "/dev/input/by-id"
+
+	// ByPathDir is the directory where udev links input devices by the
+	// bus topology path they are plugged into.
+	ByPathDir string = "/dev/input/by-path"
+
+	// KeyPrefix starts the stable keys built from sysfs by [Identify].
+	KeyPrefix string = "sysfs:"
+)
+
+// Identity lists the names of one evdev device node. Event node numbers
+// are assigned in probing order and change between boots; the by-id and
+// by-path links and the sysfs key do not.
+type Identity struct {
+	// Node is the event device node, such as /dev/input/event7.
+	Node string
+
+	// ByID lists the links to the node in [ByIDDir]. It is empty for
+	// devices udev does not link, such as virtual and most built-in
+	// devices, or when udev is not running.
+	ByID []string
+
+	// ByPath lists the links to the node in [ByPathDir].
+	ByPath []string
+
+	// Key is built from the device's sysfs attributes and is available
+	// even without udev. It has the form
+	//
+	//	sysfs:<bustype>:<vendor>:<product>:<phys>:<uniq>:<name>
+	//
+	// with the identifier fields as four hexadecimal digits. Like a by-path
+	// link it changes when the device is plugged into another port, since
+	// phys names the port.
+	Key string
+}
+
+// Stable returns the most stable name of the device: the first by-id
+// link, else the first by-path link, else the sysfs key. Any of them is
+// accepted by [NewDevice] and [ResolvePath].
+func (ident Identity) Stable() string {
+	switch {
+	case len(ident.ByID) != 0:
+		return ident.ByID[0]
+	case len(ident.ByPath) != 0:
+		return ident.ByPath[0]
+	default:
+		return ident.Key
+	}
+}
+
+// ResolvePath returns the path to open for the device identifier ident,
+// which is one of
+//   - a path, such as /dev/input/event7 or a by-id or by-path link,
+//     returned unchanged;
+//   - a by-id or by-path link relative to /dev/input, such as
+//     "by-id/usb-Logitech_USB_Receiver-event-kbd";
+//   - a sysfs key as in [Identity.Key], resolved to its event node.
+//
+// A sysfs key that matches no device returns an error classified as
+// [inputerr.ErrNotFound].
+func ResolvePath(ident string) (string, error) {
+	var (
+		dirs []string
+		dir  string
+		key  string
+		err  error
+	)
+
+	switch {
+	case strings.HasPrefix(ident, "by-id/"), strings.HasPrefix(ident, "by-path/"):
+		return filepath.Join("/dev/input", ident), nil
+	case !strings.HasPrefix(ident, KeyPrefix):
+		return ident, nil
+	}
+
+	dirs, err = filepath.Glob("/sys/class/input/event*")
+	if err != nil {
+		return "", fmt.Errorf("failed to enumerate event devices at /sys/class/input/event*: %w", err)
+	}
+
+	for _, dir = range dirs {
+		key, err = sysfsKey(dir)
+		if err != nil {
+			continue
+		}
+
+		if key == ident {
+			return filepath.Join("/dev/input", filepath.Base(dir)), nil
+		}
+	}
+
+	return "", inputerr.Wrap("failed to resolve evdev device", ident, syscall.ENOENT)
+}
+
+// Identify returns the [Identity] of the evdev device at path, which may
+// be any identifier accepted by [ResolvePath].
+func Identify(path string) (Identity, error) {
+	var (
+		stat unix.Stat_t
+		err  error
+	)
+
+	path, err = ResolvePath(path)
+	if err != nil {
+		return Identity{}, err
+	}
+
+	err = unix.Stat(path, &stat)
+	if err != nil {
+		return Identity{}, inputerr.Wrap("failed to identify evdev device", path, err)
+	}
+
+	return identify(rdev(&stat), path)
+}
+
+// Identity returns the [Identity] of the device. It works from the open
+// file, so it also identifies devices opened by [NewDeviceFromFd] or
+// received from a broker.
+func (dev *Device) Identity() (Identity, error) {
+	var (
+		stat unix.Stat_t
+		err  error
+	)
+
+	err = unix.Fstat(int(dev.file.Fd()), &stat)
+	if err != nil {
+		return Identity{}, inputerr.Wrap("failed to identify evdev device", dev.file.Name(), err)
+	}
+
+	return identify(rdev(&stat), dev.file.Name())
+}
+
+func identify(devNum uint64, name string) (Identity, error) {
+	var (
+		ident  Identity
+		sysDir string
+		err    error
+	)
+
+	sysDir = fmt.Sprintf("/sys/dev/char/%d:%d", unix.Major(devNum), unix.Minor(devNum))
+
+	sysDir, err = filepath.EvalSymlinks(sysDir)
+	if err != nil || !strings.HasPrefix(filepath.Base(sysDir), "event") {
+		return Identity{}, inputerr.Wrap("failed to identify evdev device", name, syscall.ENOTTY)
+	}
+
+	ident.Node = filepath.Join("/dev/input", filepath.Base(sysDir))
+
+	ident.Key, err = sysfsKey(sysDir)
+	if err != nil {
+		return Identity{}, inputerr.Wrap("failed to identify evdev device", name, err)
+	}
+
+	ident.ByID, err = links(ByIDDir, devNum)
+	if err != nil {
+		return Identity{}, err
+	}
+
+	ident.ByPath, err = links(ByPathDir, devNum)
+	if err != nil {
+		return Identity{}, err
+	}
+
+	return ident, nil
+}
+
+// sysfsKey builds the [Identity.Key] of the event device whose sysfs
+// directory is sysDir.
+func sysfsKey(sysDir string) (string, error) {
+	var (
+		attrs []string
+		attr  string
+		vals  []string
+		data  []byte
+		err   error
+	)
+
+	attrs = []string{"id/bustype", "id/vendor", "id/product", "phys", "uniq", "name"}
+	vals = make([]string, 0, len(attrs))
+
+	for _, attr = range attrs {
+		data, err = os.ReadFile(filepath.Join(sysDir, "device", attr))
+		if err != nil {
+			return "", err
+		}
+
+		vals = append(vals, strings.TrimSpace(string(data)))
+	}
+
+	return KeyPrefix + strings.Join(vals, ":"), nil
+}
+
+// links returns the links in dir that point to the character device
+// devNum. A missing dir yields no links.
+func links(dir string, devNum uint64) ([]string, error) {
+	var (
+		entries []fs.DirEntry
+		entry   fs.DirEntry
+		path    string
+		stat    unix.Stat_t
+		paths   []string
+		err     error
+	)
+
+	entries, err = os.ReadDir(dir)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", dir, err)
+	}
+
+	for _, entry = range entries {
+		path = filepath.Join(dir, entry.Name())
+
+		err = unix.Stat(path, &stat)
+		if err != nil || stat.Mode&unix.S_IFMT != unix.S_IFCHR || rdev(&stat) != devNum {
+			continue
+		}
+
+		paths = append(paths, path)
+	}
+
+	slices.Sort(paths)
+
+	return paths, nil
+}
+
+// rdev returns the device number of a character device.
+func rdev(stat *unix.Stat_t) uint64 {
+	//nolint:unconvert // reason: Rdev is 32 bits wide on some platforms
+	return uint64(stat.Rdev)
+}
@@ -3,6 +3,7 @@ package evdev
 import (
 	"errors"
 	"fmt"
+	"syscall"
 
 	"github.com/andrieee44/gopkg/linux/uapi/input"
 )
@@ -10,7 +11,7 @@ import (
 // Snapshot captures a point-in-time view of a [Device]'s state and
 // capabilities. A Snapshot includes identifiers, repeat settings, enabled
 // and supported events, absolute axis metadata, multi-touch information,
-// and descriptor fields such as Name, Filename, and Version.
+// and descriptor fields such as Name, Phys, Filename, and Version.
 type Snapshot struct {
 	// ID is the evdev device’s identifier.
 	ID input.ID
@@ -51,6 +52,10 @@ type Snapshot struct {
 	// by the device.
 	ForceFeedback []input.FFCode
 
+	// FFEffects is how many force‑feedback effects the device can
+	// store. It is zero for devices without force feedback.
+	FFEffects int32
+
 	// Power lists the power‑management key codes supported by the
 	// device.
 	Power []input.KeyCode
@@ -66,6 +71,14 @@ type Snapshot struct {
 	// Name is the evdev device’s name.
 	Name string
 
+	// Phys is the evdev device’s physical location, or empty if it has
+	// none.
+	Phys string
+
+	// Uniq is the evdev device’s unique identifier, such as a serial
+	// number, or empty if it has none.
+	Uniq string
+
 	// Filename is the name of the underlying file.
 	Filename string
 
@@ -118,6 +131,10 @@ type SnapshotPretty struct {
 	// by the device.
 	ForceFeedback []string
 
+	// FFEffects is how many force‑feedback effects the device can
+	// store. It is zero for devices without force feedback.
+	FFEffects int32
+
 	// Power lists the power‑management key codes supported by the
 	// device.
 	Power []string
@@ -133,6 +150,14 @@ type SnapshotPretty struct {
 	// Name is the evdev device’s name.
 	Name string
 
+	// Phys is the evdev device’s physical location, or empty if it has
+	// none.
+	Phys string
+
+	// Uniq is the evdev device’s unique identifier, such as a serial
+	// number, or empty if it has none.
+	Uniq string
+
 	// Filename is the name of the underlying file.
 	Filename string
 
@@ -157,10 +182,13 @@ func (snap *Snapshot) Pretty() *SnapshotPretty {
 		Relative:            slicePretty(snap.Relative),
 		Misc:                slicePretty(snap.Misc),
 		ForceFeedback:       slicePretty(snap.ForceFeedback),
+		FFEffects:           snap.FFEffects,
 		Power:               slicePretty(snap.Power),
 		ForceFeedbackStatus: slicePretty(snap.ForceFeedbackStatus),
 		Properties:          slicePretty(snap.Properties),
 		Name:                snap.Name,
+		Phys:                snap.Phys,
+		Uniq:                snap.Uniq,
 		Filename:            snap.Filename,
 		Version:             snap.Version,
 	}
@@ -185,6 +213,19 @@ func (snap *Snapshot) repeat() error {
 	return nil
 }
 
+func (snap *Snapshot) forceFeedback() error {
+	var err error
+
+	err = supported(&snap.ForceFeedback, snap.dev.ForceFeedbacks)
+	if err != nil {
+		return err
+	}
+
+	snap.FFEffects, err = snap.dev.FFEffects()
+
+	return err
+}
+
 func (snap *Snapshot) absolute() error {
 	var (
 		codes   []input.AbsoluteCode
@@ -252,7 +293,7 @@ func (snap *Snapshot) dispatcher(events []input.EventCode) error {
 		case input.EV_MSC:
 			err = supported(&snap.Misc, snap.dev.Miscs)
 		case input.EV_FF:
-			err = supported(&snap.ForceFeedback, snap.dev.ForceFeedbacks)
+			err = snap.forceFeedback()
 		case input.EV_PWR:
 			err = supported(&snap.Power, snap.dev.Powers)
 		case input.EV_FF_STATUS:
@@ -396,6 +437,16 @@ func newSnapshot(dev *Device) (*Snapshot, error) {
 		return nil, err
 	}
 
+	snap.Phys, err = optionalString(dev.PhysicalLocation)
+	if err != nil {
+		return nil, err
+	}
+
+	snap.Uniq, err = optionalString(dev.UniqueID)
+	if err != nil {
+		return nil, err
+	}
+
 	snap.Properties, err = dev.Properties()
 	if err != nil {
 		return nil, err
@@ -413,3 +464,19 @@ func newSnapshot(dev *Device) (*Snapshot, error) {
 
 	return snap, nil
 }
+
+// optionalString returns the string read by get, or an empty string for
+// a device that has none, which the kernel reports as ENOENT.
+func optionalString(get func(bufSize uint32) (string, error)) (string, error) {
+	var (
+		str string
+		err error
+	)
+
+	str, err = get(256)
+	if errors.Is(err, syscall.ENOENT) {
+		return "", nil
+	}
+
+	return str, err
+}
@@ -0,0 +1,134 @@
+package gamepad
+
+import (
+	"github.com/andrieee44/gopkg/linux/evdev"
+	"github.com/andrieee44/gopkg/linux/uapi/input"
+)
+
+var defaultButtons map[Button]input.KeyCode = map[Button]input.KeyCode{
+	ButtonA:             input.BTN_SOUTH,
+	ButtonB:             input.BTN_EAST,
+	ButtonX:             input.BTN_WEST,
+	ButtonY:             input.BTN_NORTH,
+	ButtonBack:          input.BTN_SELECT,
+	ButtonGuide:         input.BTN_MODE,
+	ButtonStart:         input.BTN_START,
+	ButtonLeftStick:     input.BTN_THUMBL,
+	ButtonRightStick:    input.BTN_THUMBR,
+	ButtonLeftShoulder:  input.BTN_TL,
+	ButtonRightShoulder: input.BTN_TR,
+	ButtonDPadUp:        input.BTN_DPAD_UP,
+	ButtonDPadDown:      input.BTN_DPAD_DOWN,
+	ButtonDPadLeft:      input.BTN_DPAD_LEFT,
+	ButtonDPadRight:     input.BTN_DPAD_RIGHT,
+}
+
+var defaultAxes map[Axis]input.AbsoluteCode = map[Axis]input.AbsoluteCode{
+	AxisLeftX:        input.ABS_X,
+	AxisLeftY:        input.ABS_Y,
+	AxisRightX:       input.ABS_RX,
+	AxisRightY:       input.ABS_RY,
+	AxisLeftTrigger:  input.ABS_Z,
+	AxisRightTrigger: input.ABS_RZ,
+}
+
+var defaultTriggerButtons map[Axis]input.KeyCode = map[Axis]input.KeyCode{
+	AxisLeftTrigger:  input.BTN_TL2,
+	AxisRightTrigger: input.BTN_TR2,
+}
+
+var defaultHat map[Button]uint8 = map[Button]uint8{
+	ButtonDPadUp:    1,
+	ButtonDPadRight: 2,
+	ButtonDPadDown:  4,
+	ButtonDPadLeft:  8,
+}
+
+// DefaultMapping returns a mapping for a controller that follows the
+// kernel's gamepad conventions described in
+// Documentation/input/gamepad.rst, as most controllers with an in-kernel
+// driver do. The d-pad is taken from the BTN_DPAD_* buttons or, failing
+// that, the first hat, and triggers from [input.ABS_Z] and [input.ABS_RZ]
+// or, failing that, [input.BTN_TL2] and [input.BTN_TR2]. It reports false
+// if the controller does not report [input.BTN_GAMEPAD].
+func DefaultMapping(snap *evdev.Snapshot) (*Mapping, bool) {
+	var (
+		layout  *Gamepad
+		mapping *Mapping
+		button  Button
+		ax      Axis
+		key     input.KeyCode
+		abs     input.AbsoluteCode
+		mask    uint8
+		idx     int
+		found   bool
+	)
+
+	_, found = snap.Key[input.BTN_GAMEPAD]
+	if !found {
+		return nil, false
+	}
+
+	layout = NewGamepad(&Mapping{}, snap)
+	mapping = &Mapping{
+		GUID:     NewGUID(snap.ID, snap.Name),
+		Name:     snap.Name,
+		Platform: "Linux",
+	}
+
+	for button = range ButtonCount {
+		key, found = defaultButtons[button]
+		if !found {
+			continue
+		}
+
+		idx, found = layout.buttonIndex[key]
+		if found {
+			mapping.Bindings = append(mapping.Bindings, Binding{
+				Target: Target{Button: button},
+				Source: Source{Kind: SourceButton, Index: idx},
+			})
+
+			continue
+		}
+
+		mask, found = defaultHat[button]
+		if !found || len(layout.rawHats) == 0 {
+			continue
+		}
+
+		mapping.Bindings = append(mapping.Bindings, Binding{
+			Target: Target{Button: button},
+			Source: Source{Kind: SourceHat, HatMask: mask},
+		})
+	}
+
+	for ax = range AxisCount {
+		abs = defaultAxes[ax]
+
+		idx, found = layout.axisIndex[abs]
+		if found {
+			mapping.Bindings = append(mapping.Bindings, Binding{
+				Target: Target{Axis: ax, IsAxis: true},
+				Source: Source{Kind: SourceAxis, Index: idx},
+			})
+
+			continue
+		}
+
+		key, found = defaultTriggerButtons[ax]
+		if !found {
+			continue
+		}
+
+		idx, found = layout.buttonIndex[key]
+		if found {
+			mapping.Bindings = append(mapping.Bindings, Binding{
+				Target: Target{Axis: ax, IsAxis: true},
+				Source: Source{Kind: SourceButton, Index: idx},
+			})
+		}
+	}
+
+	return mapping, true
+}
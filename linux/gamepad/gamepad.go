@@ -0,0 +1,644 @@
+// Package gamepad presents game controllers in a standard layout, so
+// software can ask for the A button or the left stick instead of
+// hard-coding the raw [input.KeyCode] and [input.AbsoluteCode] values of
+// every controller model.
+//
+// Controllers are matched to mappings from SDL's gamecontrollerdb.txt by
+// a [GUID] derived from their [input.ID]. Mappings may bind buttons, axes
+// or hat directions to any standard control, so hats and axes can drive
+// buttons and buttons can drive axes. Controllers that follow the
+// kernel's gamepad conventions work without a mapping.
+//
+// In the other direction, [NewVirtualDevice] creates a virtual Xbox 360
+// style controller that a [Virtual] drives from standard controls, for
+// bridging network or Bluetooth controllers into games. Its rumble
+// effects are handled by a [Rumbler].
+package gamepad
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/andrieee44/gopkg/linux/axis"
+	"github.com/andrieee44/gopkg/linux/evdev"
+	"github.com/andrieee44/gopkg/linux/uapi/input"
+)
+
+// Button is a standard gamepad button.
+type Button uint8
+
+const (
+	// ButtonA is the bottom face button.
+	ButtonA Button = iota
+
+	// ButtonB is the right face button.
+	ButtonB
+
+	// ButtonX is the left face button.
+	ButtonX
+
+	// ButtonY is the top face button.
+	ButtonY
+
+	// ButtonBack is the back or select button.
+	ButtonBack
+
+	// ButtonGuide is the home or guide button.
+	ButtonGuide
+
+	// ButtonStart is the start button.
+	ButtonStart
+
+	// ButtonLeftStick is the left stick click.
+	ButtonLeftStick
+
+	// ButtonRightStick is the right stick click.
+	ButtonRightStick
+
+	// ButtonLeftShoulder is the left shoulder bumper.
+	ButtonLeftShoulder
+
+	// ButtonRightShoulder is the right shoulder bumper.
+	ButtonRightShoulder
+
+	// ButtonDPadUp is the d-pad up direction.
+	ButtonDPadUp
+
+	// ButtonDPadDown is the d-pad down direction.
+	ButtonDPadDown
+
+	// ButtonDPadLeft is the d-pad left direction.
+	ButtonDPadLeft
+
+	// ButtonDPadRight is the d-pad right direction.
+	ButtonDPadRight
+
+	// ButtonMisc1 is an extra button, such as a share or capture button.
+	ButtonMisc1
+
+	// ButtonPaddle1 is the upper right back paddle.
+	ButtonPaddle1
+
+	// ButtonPaddle2 is the upper left back paddle.
+	ButtonPaddle2
+
+	// ButtonPaddle3 is the lower right back paddle.
+	ButtonPaddle3
+
+	// ButtonPaddle4 is the lower left back paddle.
+	ButtonPaddle4
+
+	// ButtonTouchpad is the touchpad click.
+	ButtonTouchpad
+
+	// ButtonCount is the number of standard buttons.
+	ButtonCount
+)
+
+// Axis is a standard gamepad axis.
+type Axis uint8
+
+const (
+	// AxisLeftX is the left stick's horizontal axis.
+	AxisLeftX Axis = iota
+
+	// AxisLeftY is the left stick's vertical axis.
+	AxisLeftY
+
+	// AxisRightX is the right stick's horizontal axis.
+	AxisRightX
+
+	// AxisRightY is the right stick's vertical axis.
+	AxisRightY
+
+	// AxisLeftTrigger is the left analog trigger.
+	AxisLeftTrigger
+
+	// AxisRightTrigger is the right analog trigger.
+	AxisRightTrigger
+
+	// AxisCount is the number of standard axes.
+	AxisCount
+)
+
+// State is the state of every standard control. Stick axes are in
+// [-1, 1] with down and right positive, and triggers are in [0, 1].
+type State struct {
+	// Buttons reports whether each button is pressed.
+	Buttons [ButtonCount]bool
+
+	// Axes reports the position of each axis.
+	Axes [AxisCount]float64
+}
+
+// Event reports a change of a standard control.
+type Event struct {
+	// Time is the time of the input frame that caused the change.
+	Time time.Time
+
+	// Button is the button that changed, if IsAxis is false.
+	Button Button
+
+	// Axis is the axis that changed, if IsAxis is true.
+	Axis Axis
+
+	// IsAxis reports whether the event is for an axis.
+	IsAxis bool
+
+	// Pressed is the new button state.
+	Pressed bool
+
+	// Value is the new axis position, or 1 and 0 for a pressed and
+	// released button.
+	Value float64
+}
+
+// Gamepad translates the events of a controller into standard controls
+// using a [Mapping]. It is not safe for concurrent use.
+type Gamepad struct {
+	// Mapping is the mapping in use.
+	Mapping *Mapping
+
+	dev         *evdev.Device
+	buttons     []input.KeyCode
+	buttonIndex map[input.KeyCode]int
+	axes        []*axis.Axis
+	axisIndex   map[input.AbsoluteCode]int
+	hatIndex    map[int]int
+	hatAxes     map[input.AbsoluteCode]*axis.Axis
+	rawButtons  []bool
+	rawAxes     []float64
+	rawHats     []uint8
+	state       State
+}
+
+var (
+	// ErrNoMapping is returned by [Open] for a device that has no
+	// mapping in the database and does not follow the kernel's gamepad
+	// conventions.
+	ErrNoMapping error = errors.New("no controller mapping for device")
+
+	// ErrNoDevice is reported by [Gamepad.ReadEvents] for a [Gamepad]
+	// created without a device.
+	ErrNoDevice error = errors.New("gamepad has no device")
+)
+
+var buttonNames [ButtonCount]string = [ButtonCount]string{
+	"a",
+	"b",
+	"x",
+	"y",
+	"back",
+	"guide",
+	"start",
+	"leftstick",
+	"rightstick",
+	"leftshoulder",
+	"rightshoulder",
+	"dpup",
+	"dpdown",
+	"dpleft",
+	"dpright",
+	"misc1",
+	"paddle1",
+	"paddle2",
+	"paddle3",
+	"paddle4",
+	"touchpad",
+}
+
+var axisNames [AxisCount]string = [AxisCount]string{
+	"leftx",
+	"lefty",
+	"rightx",
+	"righty",
+	"lefttrigger",
+	"righttrigger",
+}
+
+// String returns the gamecontrollerdb.txt name of the button.
+func (button Button) String() string {
+	if button >= ButtonCount {
+		return fmt.Sprintf("Button(%d)", uint8(button))
+	}
+
+	return buttonNames[button]
+}
+
+// String returns the gamecontrollerdb.txt name of the axis.
+func (ax Axis) String() string {
+	if ax >= AxisCount {
+		return fmt.Sprintf("Axis(%d)", uint8(ax))
+	}
+
+	return axisNames[ax]
+}
+
+// IsTrigger reports whether the axis is a trigger with a range of
+// [0, 1].
+func (ax Axis) IsTrigger() bool {
+	return ax == AxisLeftTrigger || ax == AxisRightTrigger
+}
+
+// Open returns a [Gamepad] for dev using the mapping for its [GUID] in db.
+// A nil db, or a db without a matching mapping, falls back to
+// [DefaultMapping] if the device reports [input.BTN_GAMEPAD].
+func Open(dev *evdev.Device, db *DB) (*Gamepad, error) {
+	var (
+		snap    *evdev.Snapshot
+		mapping *Mapping
+		found   bool
+		pad     *Gamepad
+		err     error
+	)
+
+	snap, err = dev.Snapshot()
+	if err != nil {
+		return nil, fmt.Errorf("failed to open gamepad: %w", err)
+	}
+
+	if db != nil {
+		mapping, found = db.Lookup(NewGUID(snap.ID, snap.Name))
+	}
+
+	if !found {
+		mapping, found = DefaultMapping(snap)
+	}
+
+	if !found {
+		return nil, fmt.Errorf("%s: %w", dev.Filename(), ErrNoMapping)
+	}
+
+	pad = NewGamepad(mapping, snap)
+	pad.dev = dev
+
+	return pad, nil
+}
+
+// NewGamepad returns a [Gamepad] for a controller with the capabilities
+// and initial state in snap. It numbers the controller's inputs the way
+// SDL does: buttons from [input.BTN_JOYSTICK] up, followed by those
+// below it; absolute axes in code order, skipping hats; and hats in
+// order of the [input.ABS_HAT0X] to [input.ABS_HAT3Y] pairs present.
+func NewGamepad(mapping *Mapping, snap *evdev.Snapshot) *Gamepad {
+	var (
+		pad     *Gamepad
+		key     input.KeyCode
+		abs     input.AbsoluteCode
+		info    input.AbsInfo
+		found   bool
+		pressed bool
+		hat     int
+	)
+
+	pad = &Gamepad{
+		Mapping:     mapping,
+		buttonIndex: make(map[input.KeyCode]int),
+		axisIndex:   make(map[input.AbsoluteCode]int),
+		hatIndex:    make(map[int]int),
+		hatAxes:     make(map[input.AbsoluteCode]*axis.Axis),
+	}
+
+	for key = input.BTN_JOYSTICK; key < input.KEY_MAX; key++ {
+		pad.addButton(snap, key)
+	}
+
+	for key = 0; key < input.BTN_JOYSTICK; key++ {
+		pad.addButton(snap, key)
+	}
+
+	for abs = 0; abs < input.ABS_MAX; abs++ {
+		info, found = snap.Absolute[abs]
+		if !found {
+			continue
+		}
+
+		if abs >= input.ABS_HAT0X && abs <= input.ABS_HAT3Y {
+			hat = int(abs-input.ABS_HAT0X) / 2
+
+			_, found = pad.hatIndex[hat]
+			if !found {
+				pad.hatIndex[hat] = len(pad.rawHats)
+				pad.rawHats = append(pad.rawHats, 0)
+			}
+
+			pad.hatAxes[abs] = axis.NewAxis(info, axis.Bipolar)
+
+			continue
+		}
+
+		pad.axisIndex[abs] = len(pad.axes)
+		pad.axes = append(pad.axes, axis.NewAxis(info, axis.Bipolar))
+		pad.rawAxes = append(pad.rawAxes, 0)
+	}
+
+	pad.rawButtons = make([]bool, len(pad.buttons))
+
+	for key, pressed = range snap.Key {
+		pad.setButton(key, pressed)
+	}
+
+	for abs, info = range snap.Absolute {
+		pad.setAbs(abs, info.Value)
+	}
+
+	pad.state = pad.compute()
+
+	return pad
+}
+
+// State returns the current state of the standard controls.
+func (pad *Gamepad) State() State {
+	return pad.state
+}
+
+// Update processes a raw event from the controller. Changes are collected
+// until the [input.SYN_REPORT] that ends the frame, which returns an
+// [Event] for each standard control whose state changed.
+func (pad *Gamepad) Update(event input.Event) []Event {
+	var (
+		next   State
+		at     time.Time
+		events []Event
+		button Button
+		ax     Axis
+	)
+
+	switch event.Type {
+	case input.EV_KEY:
+		pad.setButton(input.KeyCode(event.Code), event.Value != 0)
+
+		return nil
+	case input.EV_ABS:
+		pad.setAbs(input.AbsoluteCode(event.Code), event.Value)
+
+		return nil
+	case input.EV_SYN:
+		if input.SyncCode(event.Code) != input.SYN_REPORT {
+			return nil
+		}
+	default:
+		return nil
+	}
+
+	next = pad.compute()
+	at = event.Time.Time()
+
+	for button = range ButtonCount {
+		if next.Buttons[button] == pad.state.Buttons[button] {
+			continue
+		}
+
+		events = append(events, Event{
+			Time:    at,
+			Button:  button,
+			Pressed: next.Buttons[button],
+			Value:   boolValue(next.Buttons[button]),
+		})
+	}
+
+	for ax = range AxisCount {
+		if next.Axes[ax] == pad.state.Axes[ax] {
+			continue
+		}
+
+		events = append(events, Event{
+			Time:   at,
+			Axis:   ax,
+			IsAxis: true,
+			Value:  next.Axes[ax],
+		})
+	}
+
+	pad.state = next
+
+	return events
+}
+
+// ReadEvents reads the controller and returns channels delivering its
+// standard control events and read errors. Both channels are closed when
+// the device stops delivering events. It reports [ErrNoDevice] for a
+// [Gamepad] created with [NewGamepad].
+func (pad *Gamepad) ReadEvents() (<-chan Event, <-chan error) {
+	var (
+		eventsChan chan Event
+		errChan    chan error
+		rawEvents  <-chan input.Event
+		rawErrs    <-chan error
+	)
+
+	eventsChan = make(chan Event)
+	errChan = make(chan error, 1)
+
+	if pad.dev == nil {
+		errChan <- ErrNoDevice
+
+		close(eventsChan)
+		close(errChan)
+
+		return eventsChan, errChan
+	}
+
+	rawEvents, rawErrs = pad.dev.ReadEvents()
+
+	go pad.serve(rawEvents, rawErrs, eventsChan, errChan)
+
+	return eventsChan, errChan
+}
+
+func (pad *Gamepad) serve(
+	rawEvents <-chan input.Event,
+	rawErrs <-chan error,
+	eventsChan chan<- Event,
+	errChan chan<- error,
+) {
+	var (
+		raw   input.Event
+		event Event
+		ok    bool
+		err   error
+	)
+
+	for rawEvents != nil || rawErrs != nil {
+		select {
+		case raw, ok = <-rawEvents:
+			if !ok {
+				rawEvents = nil
+
+				continue
+			}
+
+			for _, event = range pad.Update(raw) {
+				eventsChan <- event
+			}
+		case err, ok = <-rawErrs:
+			if !ok {
+				rawErrs = nil
+
+				continue
+			}
+
+			errChan <- fmt.Errorf("%s: failed to read gamepad: %w", pad.dev.Filename(), err)
+		}
+	}
+
+	close(eventsChan)
+	close(errChan)
+}
+
+func (pad *Gamepad) addButton(snap *evdev.Snapshot, key input.KeyCode) {
+	var found bool
+
+	_, found = snap.Key[key]
+	if !found {
+		return
+	}
+
+	pad.buttonIndex[key] = len(pad.buttons)
+	pad.buttons = append(pad.buttons, key)
+}
+
+func (pad *Gamepad) setButton(key input.KeyCode, pressed bool) {
+	var (
+		idx   int
+		found bool
+	)
+
+	idx, found = pad.buttonIndex[key]
+	if found {
+		pad.rawButtons[idx] = pressed
+	}
+}
+
+func (pad *Gamepad) setAbs(abs input.AbsoluteCode, value int32) {
+	var (
+		hatAxis   *axis.Axis
+		idx, hat  int
+		found     bool
+		scaled    float64
+		low, high uint8
+	)
+
+	idx, found = pad.axisIndex[abs]
+	if found {
+		pad.rawAxes[idx] = pad.axes[idx].Scale(pad.axes[idx].Defuzz(value))
+
+		return
+	}
+
+	hatAxis, found = pad.hatAxes[abs]
+	if !found {
+		return
+	}
+
+	hat = pad.hatIndex[int(abs-input.ABS_HAT0X)/2]
+
+	low, high = 8, 2
+	if (abs-input.ABS_HAT0X)%2 == 1 {
+		low, high = 1, 4
+	}
+
+	pad.rawHats[hat] &^= low | high
+
+	scaled = hatAxis.Scale(value)
+	switch {
+	case scaled <= -0.5:
+		pad.rawHats[hat] |= low
+	case scaled >= 0.5:
+		pad.rawHats[hat] |= high
+	}
+}
+
+func (pad *Gamepad) compute() State {
+	var (
+		state   State
+		binding Binding
+		value   float64
+		ax      Axis
+	)
+
+	for _, binding = range pad.Mapping.Bindings {
+		value = pad.unipolar(binding.Source)
+
+		if !binding.Target.IsAxis {
+			if binding.Target.Button < ButtonCount && value > 0.5 {
+				state.Buttons[binding.Target.Button] = true
+			}
+
+			continue
+		}
+
+		ax = binding.Target.Axis
+		if ax >= AxisCount {
+			continue
+		}
+
+		switch {
+		case binding.Target.Half != Full:
+			value *= float64(binding.Target.Half)
+		case !ax.IsTrigger() && binding.Source.Kind == SourceAxis && binding.Source.Half == Full:
+			value = value*2 - 1
+		}
+
+		state.Axes[ax] += value
+	}
+
+	for ax = range AxisCount {
+		if ax.IsTrigger() {
+			state.Axes[ax] = math.Max(0, math.Min(1, state.Axes[ax]))
+
+			continue
+		}
+
+		state.Axes[ax] = math.Max(-1, math.Min(1, state.Axes[ax]))
+	}
+
+	return state
+}
+
+// unipolar returns the value of source in [0, 1]. Full axes are mapped
+// from [-1, 1], half axes report their distance from the centre in the
+// selected direction, and buttons and hat directions report 0 or 1.
+func (pad *Gamepad) unipolar(source Source) float64 {
+	var value float64
+
+	switch source.Kind {
+	case SourceButton:
+		if source.Index >= len(pad.rawButtons) {
+			return 0
+		}
+
+		return boolValue(pad.rawButtons[source.Index])
+	case SourceHat:
+		if source.Index >= len(pad.rawHats) {
+			return 0
+		}
+
+		return boolValue(pad.rawHats[source.Index]&source.HatMask != 0)
+	}
+
+	if source.Index >= len(pad.rawAxes) {
+		return 0
+	}
+
+	value = pad.rawAxes[source.Index]
+	if source.Invert {
+		value = -value
+	}
+
+	if source.Half == Full {
+		return (value + 1) / 2
+	}
+
+	return math.Max(0, value*float64(source.Half))
+}
+
+func boolValue(value bool) float64 {
+	if value {
+		return 1
+	}
+
+	return 0
+}
@@ -0,0 +1,263 @@
+package gamepad_test
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/andrieee44/gopkg/linux/evdev"
+	"github.com/andrieee44/gopkg/linux/gamepad"
+	"github.com/andrieee44/gopkg/linux/uapi/input"
+)
+
+const xbox360 string = "030000005e0400008e02000014010000,Xbox 360 Controller," +
+	"a:b0,b:b1,back:b6,dpdown:h0.4,dpleft:h0.8,dpright:h0.2,dpup:h0.1,guide:b8," +
+	"leftshoulder:b4,leftstick:b9,lefttrigger:a2,leftx:a0,lefty:a1,rightshoulder:b5," +
+	"rightstick:b10,righttrigger:a5,rightx:a3,righty:a4,start:b7,x:b2,y:b3,platform:Linux,"
+
+func xpadSnapshot() *evdev.Snapshot {
+	var (
+		stick, trigger, hat input.AbsInfo
+		key                 input.KeyCode
+		snap                *evdev.Snapshot
+	)
+
+	stick = input.AbsInfo{Minimum: -32768, Maximum: 32767}
+	trigger = input.AbsInfo{Minimum: 0, Maximum: 255}
+	hat = input.AbsInfo{Minimum: -1, Maximum: 1}
+
+	snap = &evdev.Snapshot{
+		ID: input.ID{
+			Bustype: 0x03,
+			Vendor:  0x045e,
+			Product: 0x028e,
+			Version: 0x0114,
+		},
+		Name: "Microsoft X-Box 360 pad",
+		Key:  make(map[input.KeyCode]bool),
+		Absolute: map[input.AbsoluteCode]input.AbsInfo{
+			input.ABS_X:     stick,
+			input.ABS_Y:     stick,
+			input.ABS_Z:     trigger,
+			input.ABS_RX:    stick,
+			input.ABS_RY:    stick,
+			input.ABS_RZ:    trigger,
+			input.ABS_HAT0X: hat,
+			input.ABS_HAT0Y: hat,
+		},
+	}
+
+	for _, key = range []input.KeyCode{
+		input.BTN_SOUTH,
+		input.BTN_EAST,
+		input.BTN_NORTH,
+		input.BTN_WEST,
+		input.BTN_TL,
+		input.BTN_TR,
+		input.BTN_SELECT,
+		input.BTN_START,
+		input.BTN_MODE,
+		input.BTN_THUMBL,
+		input.BTN_THUMBR,
+	} {
+		snap.Key[key] = false
+	}
+
+	return snap
+}
+
+func TestGUID(t *testing.T) {
+	var (
+		guid, parsed gamepad.GUID
+		err          error
+	)
+
+	t.Parallel()
+
+	guid = gamepad.NewGUID(input.ID{Bustype: 0x03, Vendor: 0x045e, Product: 0x028e, Version: 0x0114}, "123456789")
+
+	if guid.String() != "03003dbb5e0400008e02000014010000" {
+		t.Errorf("got: %s, exp: %s", guid, "03003dbb5e0400008e02000014010000")
+	}
+
+	if guid.WithoutVersion().String() != "030000005e0400008e02000000000000" {
+		t.Errorf("without version: got: %s", guid.WithoutVersion())
+	}
+
+	parsed, err = gamepad.ParseGUID(guid.String())
+	if err != nil || parsed != guid {
+		t.Errorf("parse: got: (%s, %v), exp: (%s, nil)", parsed, err, guid)
+	}
+
+	_, err = gamepad.ParseGUID("0300")
+	if !errors.Is(err, gamepad.ErrInvalidGUID) {
+		t.Errorf("short: got: %v, exp: %v", err, gamepad.ErrInvalidGUID)
+	}
+}
+
+func TestParseMapping(t *testing.T) {
+	type table struct {
+		line string
+		exp  string
+	}
+
+	var (
+		tests   []table
+		test    table
+		line    string
+		mapping *gamepad.Mapping
+		err     error
+	)
+
+	t.Parallel()
+
+	tests = []table{
+		{xbox360, xbox360},
+		{
+			"03000000000000000000000000000000,Pad,a:b0,-leftx:h0.8,+leftx:h0.2,lefttrigger:+a2~,crc:1234,",
+			"03000000000000000000000000000000,Pad,a:b0,-leftx:h0.8,+leftx:h0.2,lefttrigger:+a2~,",
+		},
+	}
+
+	for _, test = range tests {
+		mapping, err = gamepad.ParseMapping(test.line)
+		if err != nil {
+			t.Errorf("%s: %v", test.line, err)
+
+			continue
+		}
+
+		if mapping.String() != test.exp {
+			t.Errorf("got: %s, exp: %s", mapping, test.exp)
+		}
+	}
+
+	for _, line = range []string{
+		"nothex,Pad,a:b0,",
+		"03000000000000000000000000000000,Pad,a:c0,",
+		"03000000000000000000000000000000,Pad,a:h0,",
+		"03000000000000000000000000000000,Pad,a,",
+	} {
+		_, err = gamepad.ParseMapping(line)
+		if err == nil {
+			t.Errorf("%s: got: nil, exp: error", line)
+		}
+	}
+}
+
+func TestDBLookup(t *testing.T) {
+	var (
+		db      *gamepad.DB
+		snap    *evdev.Snapshot
+		mapping *gamepad.Mapping
+		found   bool
+		err     error
+	)
+
+	t.Parallel()
+
+	db = gamepad.NewDB()
+
+	err = db.Load(strings.NewReader(
+		"# Comment\n\n" +
+			xbox360 + "\n" +
+			"030000005e0400008e02000014010000,Windows Pad,a:b1,platform:Windows,\n",
+	))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if db.Len() != 1 {
+		t.Errorf("len: got: %d, exp: 1", db.Len())
+	}
+
+	snap = xpadSnapshot()
+
+	mapping, found = db.Lookup(gamepad.NewGUID(snap.ID, snap.Name))
+	if !found || mapping.Name != "Xbox 360 Controller" {
+		t.Errorf("got: (%v, %t), exp: Xbox 360 Controller", mapping, found)
+	}
+
+	err = db.Load(strings.NewReader("bad line\n"))
+	if !errors.Is(err, gamepad.ErrInvalidMapping) {
+		t.Errorf("bad line: got: %v, exp: %v", err, gamepad.ErrInvalidMapping)
+	}
+}
+
+func TestUpdate(t *testing.T) {
+	var (
+		mapping *gamepad.Mapping
+		pad     *gamepad.Gamepad
+		event   input.Event
+		events  []gamepad.Event
+		state   gamepad.State
+		err     error
+	)
+
+	t.Parallel()
+
+	mapping, err = gamepad.ParseMapping(xbox360)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	pad = gamepad.NewGamepad(mapping, xpadSnapshot())
+
+	for _, event = range []input.Event{
+		{Type: input.EV_KEY, Code: uint16(input.BTN_SOUTH), Value: 1},
+		{Type: input.EV_ABS, Code: uint16(input.ABS_HAT0Y), Value: -1},
+		{Type: input.EV_ABS, Code: uint16(input.ABS_RZ), Value: 255},
+	} {
+		if pad.Update(event) != nil {
+			t.Errorf("%+v: got: events before report", event)
+		}
+	}
+
+	events = pad.Update(input.Event{Type: input.EV_SYN, Code: uint16(input.SYN_REPORT)})
+	if len(events) != 3 ||
+		events[0].Button != gamepad.ButtonA || !events[0].Pressed ||
+		events[1].Button != gamepad.ButtonDPadUp || !events[1].Pressed ||
+		events[2].Axis != gamepad.AxisRightTrigger || events[2].Value != 1 {
+		t.Errorf("got: %+v", events)
+	}
+
+	state = pad.State()
+	if !state.Buttons[gamepad.ButtonA] || state.Axes[gamepad.AxisLeftTrigger] != 0 {
+		t.Errorf("state: got: %+v", state)
+	}
+
+	pad.Update(input.Event{Type: input.EV_ABS, Code: uint16(input.ABS_HAT0Y), Value: 0})
+
+	events = pad.Update(input.Event{Type: input.EV_SYN, Code: uint16(input.SYN_REPORT)})
+	if len(events) != 1 || events[0].Button != gamepad.ButtonDPadUp || events[0].Pressed {
+		t.Errorf("release: got: %+v", events)
+	}
+}
+
+func TestDefaultMapping(t *testing.T) {
+	var (
+		snap    *evdev.Snapshot
+		mapping *gamepad.Mapping
+		found   bool
+		exp     string
+	)
+
+	t.Parallel()
+
+	snap = xpadSnapshot()
+	exp = "a:b0,b:b1,x:b3,y:b2,back:b6,guide:b8,start:b7,leftstick:b9,rightstick:b10," +
+		"leftshoulder:b4,rightshoulder:b5,dpup:h0.1,dpdown:h0.4,dpleft:h0.8,dpright:h0.2," +
+		"leftx:a0,lefty:a1,rightx:a3,righty:a4,lefttrigger:a2,righttrigger:a5,platform:Linux,"
+
+	mapping, found = gamepad.DefaultMapping(snap)
+	if !found || !strings.HasSuffix(mapping.String(), ","+exp) {
+		t.Errorf("got: (%v, %t), exp: %s", mapping, found, exp)
+	}
+
+	delete(snap.Key, input.BTN_SOUTH)
+
+	_, found = gamepad.DefaultMapping(snap)
+	if found {
+		t.Errorf("without BTN_GAMEPAD: got: %t, exp: false", found)
+	}
+}
@@ -0,0 +1,117 @@
+package gamepad
+
+import (
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+
+	"github.com/andrieee44/gopkg/linux/uapi/input"
+)
+
+// GUID identifies a controller model in the SDL game controller
+// database. On Linux it is derived from the device's [input.ID] and, for
+// devices without vendor and product identifiers, its name.
+type GUID [16]byte
+
+// ErrInvalidGUID is returned by [ParseGUID] when the string is not 32
+// hexadecimal digits.
+var ErrInvalidGUID error = errors.New("invalid controller GUID")
+
+// NewGUID returns the SDL GUID for a device with the given identifier
+// and name. The layout matches SDL's Linux joystick driver: the bus type,
+// a CRC-16 of the name, the vendor, product and version, each as a
+// little-endian 16-bit value. Devices reporting neither a vendor nor a
+// product store the start of their name in place of those fields.
+func NewGUID(id input.ID, name string) GUID {
+	var guid GUID
+
+	binary.LittleEndian.PutUint16(guid[0:], uint16(id.Bustype))
+	binary.LittleEndian.PutUint16(guid[2:], crc16([]byte(name)))
+
+	if id.Vendor == 0 && id.Product == 0 {
+		copy(guid[4:], name)
+
+		return guid
+	}
+
+	binary.LittleEndian.PutUint16(guid[4:], id.Vendor)
+	binary.LittleEndian.PutUint16(guid[8:], id.Product)
+	binary.LittleEndian.PutUint16(guid[12:], id.Version)
+
+	return guid
+}
+
+// ParseGUID parses the 32-digit hexadecimal form used in
+// gamecontrollerdb.txt.
+func ParseGUID(str string) (GUID, error) {
+	var (
+		guid GUID
+		n    int
+		err  error
+	)
+
+	if len(str) != hex.EncodedLen(len(guid)) {
+		return GUID{}, fmt.Errorf("%q: %w", str, ErrInvalidGUID)
+	}
+
+	n, err = hex.Decode(guid[:], []byte(str))
+	if err != nil || n != len(guid) {
+		return GUID{}, fmt.Errorf("%q: %w", str, ErrInvalidGUID)
+	}
+
+	return guid, nil
+}
+
+// String returns the 32-digit hexadecimal form of the GUID.
+func (guid GUID) String() string {
+	return hex.EncodeToString(guid[:])
+}
+
+// WithoutCRC returns the GUID with its name checksum cleared. Older
+// database entries do not carry the checksum.
+func (guid GUID) WithoutCRC() GUID {
+	guid[2] = 0
+	guid[3] = 0
+
+	return guid
+}
+
+// WithoutVersion returns the GUID with its name checksum and version
+// cleared, for matching entries that apply to every revision of a model.
+func (guid GUID) WithoutVersion() GUID {
+	guid = guid.WithoutCRC()
+	if guid.hasIDs() {
+		guid[12] = 0
+		guid[13] = 0
+	}
+
+	return guid
+}
+
+func (guid GUID) hasIDs() bool {
+	return guid[6] == 0 && guid[7] == 0 && guid[10] == 0 && guid[11] == 0
+}
+
+// crc16 computes the CRC-16/ARC checksum SDL uses for controller names.
+func crc16(data []byte) uint16 {
+	var (
+		crc uint16
+		b   byte
+		bit int
+	)
+
+	for _, b = range data {
+		crc ^= uint16(b)
+
+		for bit = 0; bit < 8; bit++ {
+			if crc&1 != 0 {
+				crc = crc>>1 ^ 0xA001
+			} else {
+				crc >>= 1
+			}
+		}
+	}
+
+	return crc
+}
@@ -0,0 +1,438 @@
+package gamepad
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// SourceKind identifies the kind of raw joystick input a [Source] reads.
+type SourceKind uint8
+
+const (
+	// SourceButton reads a joystick button.
+	SourceButton SourceKind = iota
+
+	// SourceAxis reads a joystick axis.
+	SourceAxis
+
+	// SourceHat reads one direction of a joystick hat.
+	SourceHat
+)
+
+// Half selects part of an axis.
+type Half int8
+
+const (
+	// Full uses the whole axis.
+	Full Half = 0
+
+	// Positive uses only the positive half of the axis.
+	Positive Half = 1
+
+	// Negative uses only the negative half of the axis.
+	Negative Half = -1
+)
+
+// Source is the raw joystick input of a [Binding], using SDL's joystick
+// numbering: buttons and axes are counted in the order described by
+// [NewGamepad], and hats are the [input.ABS_HAT0X] to [input.ABS_HAT3Y]
+// pairs.
+type Source struct {
+	// Kind is the kind of input.
+	Kind SourceKind
+
+	// Index is the button, axis or hat number.
+	Index int
+
+	// Half restricts an axis source to one direction.
+	Half Half
+
+	// Invert flips an axis source.
+	Invert bool
+
+	// HatMask is the hat direction of a hat source: 1 for up, 2 for
+	// right, 4 for down and 8 for left.
+	HatMask uint8
+}
+
+// Target is the standard control a [Binding] drives. Exactly one of
+// Button and Axis is meaningful, selected by IsAxis.
+type Target struct {
+	// Button is the target button.
+	Button Button
+
+	// Axis is the target axis.
+	Axis Axis
+
+	// IsAxis reports whether the target is an axis.
+	IsAxis bool
+
+	// Half restricts an axis target to one direction, as for a d-pad
+	// mapped onto a stick.
+	Half Half
+}
+
+// Binding connects one raw joystick input to a standard control.
+type Binding struct {
+	// Target is the standard control.
+	Target Target
+
+	// Source is the raw joystick input.
+	Source Source
+}
+
+// Mapping describes how a controller model's raw inputs map onto the
+// standard layout. It corresponds to one line of gamecontrollerdb.txt.
+type Mapping struct {
+	// GUID identifies the controller model.
+	GUID GUID
+
+	// Name is the human readable controller name.
+	Name string
+
+	// Platform is the platform the mapping applies to, such as Linux. It
+	// is empty if the mapping does not name one.
+	Platform string
+
+	// Bindings lists the mapped controls.
+	Bindings []Binding
+}
+
+// DB is a set of [Mapping] values indexed by [GUID]. It is safe for
+// concurrent use. The zero value is an empty database ready to use.
+type DB struct {
+	mu       sync.RWMutex
+	mappings map[GUID]*Mapping
+}
+
+// ErrInvalidMapping is returned when a mapping line cannot be parsed.
+var ErrInvalidMapping error = errors.New("invalid controller mapping")
+
+// ParseMapping parses a single gamecontrollerdb.txt line of the form
+//
+//	GUID,name,a:b0,b:b1,leftx:a0,dpup:h0.1,+righty:+a4,platform:Linux,
+//
+// Fields naming controls this package does not know, such as crc and
+// hint, are ignored so newer database files keep loading.
+func ParseMapping(line string) (*Mapping, error) {
+	var (
+		mapping    *Mapping
+		fields     []string
+		field      string
+		key, value string
+		found      bool
+		target     Target
+		source     Source
+		known      bool
+		err        error
+	)
+
+	fields = strings.Split(strings.TrimSpace(line), ",")
+	if len(fields) < 2 {
+		return nil, fmt.Errorf("%q: missing name: %w", line, ErrInvalidMapping)
+	}
+
+	mapping = &Mapping{Name: fields[1]}
+
+	mapping.GUID, err = ParseGUID(fields[0])
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse controller mapping: %w", err)
+	}
+
+	for _, field = range fields[2:] {
+		if field == "" {
+			continue
+		}
+
+		key, value, found = strings.Cut(field, ":")
+		if !found {
+			return nil, fmt.Errorf("%q: missing colon: %w", field, ErrInvalidMapping)
+		}
+
+		if key == "platform" {
+			mapping.Platform = value
+
+			continue
+		}
+
+		target, known = parseTarget(key)
+		if !known {
+			continue
+		}
+
+		source, err = parseSource(value)
+		if err != nil {
+			return nil, fmt.Errorf("%q: %w", field, err)
+		}
+
+		mapping.Bindings = append(mapping.Bindings, Binding{
+			Target: target,
+			Source: source,
+		})
+	}
+
+	return mapping, nil
+}
+
+// String formats the mapping as a gamecontrollerdb.txt line.
+func (mapping *Mapping) String() string {
+	var (
+		builder strings.Builder
+		binding Binding
+	)
+
+	builder.WriteString(mapping.GUID.String())
+	builder.WriteByte(',')
+	builder.WriteString(mapping.Name)
+	builder.WriteByte(',')
+
+	for _, binding = range mapping.Bindings {
+		builder.WriteString(binding.Target.String())
+		builder.WriteByte(':')
+		builder.WriteString(binding.Source.String())
+		builder.WriteByte(',')
+	}
+
+	if mapping.Platform != "" {
+		builder.WriteString("platform:")
+		builder.WriteString(mapping.Platform)
+		builder.WriteByte(',')
+	}
+
+	return builder.String()
+}
+
+// String returns the target in gamecontrollerdb.txt notation.
+func (target Target) String() string {
+	if !target.IsAxis {
+		return target.Button.String()
+	}
+
+	return halfPrefix(target.Half) + target.Axis.String()
+}
+
+// String returns the source in gamecontrollerdb.txt notation.
+func (source Source) String() string {
+	var str string
+
+	switch source.Kind {
+	case SourceButton:
+		return "b" + strconv.Itoa(source.Index)
+	case SourceHat:
+		return fmt.Sprintf("h%d.%d", source.Index, source.HatMask)
+	}
+
+	str = halfPrefix(source.Half) + "a" + strconv.Itoa(source.Index)
+	if source.Invert {
+		str += "~"
+	}
+
+	return str
+}
+
+// NewDB returns an empty [DB].
+func NewDB() *DB {
+	return &DB{}
+}
+
+// Load reads gamecontrollerdb.txt formatted mappings from reader and adds
+// them to db. Blank lines, comments and mappings for platforms other than
+// Linux are skipped. Load stops at the first malformed line and reports
+// its line number.
+func (db *DB) Load(reader io.Reader) error {
+	var (
+		scanner *bufio.Scanner
+		line    string
+		lineNum int
+		mapping *Mapping
+		err     error
+	)
+
+	scanner = bufio.NewScanner(reader)
+
+	for scanner.Scan() {
+		lineNum++
+
+		line = strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		mapping, err = ParseMapping(line)
+		if err != nil {
+			return fmt.Errorf("line %d: %w", lineNum, err)
+		}
+
+		if mapping.Platform != "" && mapping.Platform != "Linux" {
+			continue
+		}
+
+		db.Add(mapping)
+	}
+
+	err = scanner.Err()
+	if err != nil {
+		return fmt.Errorf("failed to read controller mappings: %w", err)
+	}
+
+	return nil
+}
+
+// Add stores mapping in db, replacing any mapping with the same GUID.
+func (db *DB) Add(mapping *Mapping) {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	if db.mappings == nil {
+		db.mappings = make(map[GUID]*Mapping)
+	}
+
+	db.mappings[mapping.GUID] = mapping
+}
+
+// Lookup returns the mapping for guid. Entries without a name checksum
+// or a version match any device of the same model, as they do in SDL.
+func (db *DB) Lookup(guid GUID) (*Mapping, bool) {
+	var (
+		candidate GUID
+		mapping   *Mapping
+		found     bool
+	)
+
+	db.mu.RLock()
+	defer db.mu.RUnlock()
+
+	for _, candidate = range []GUID{guid, guid.WithoutCRC(), guid.WithoutVersion()} {
+		mapping, found = db.mappings[candidate]
+		if found {
+			return mapping, true
+		}
+	}
+
+	return nil, false
+}
+
+// Len returns the number of mappings in db.
+func (db *DB) Len() int {
+	db.mu.RLock()
+	defer db.mu.RUnlock()
+
+	return len(db.mappings)
+}
+
+func parseTarget(key string) (Target, bool) {
+	var (
+		half   Half
+		button Button
+		axis   Axis
+	)
+
+	key, half = trimHalf(key)
+
+	for axis = range AxisCount {
+		if key == axis.String() {
+			return Target{Axis: axis, IsAxis: true, Half: half}, true
+		}
+	}
+
+	if half != Full {
+		return Target{}, false
+	}
+
+	for button = range ButtonCount {
+		if key == button.String() {
+			return Target{Button: button}, true
+		}
+	}
+
+	return Target{}, false
+}
+
+func parseSource(value string) (Source, error) {
+	var (
+		source      Source
+		hat, mask   string
+		found       bool
+		index, bits int
+		err         error
+	)
+
+	value, source.Half = trimHalf(value)
+
+	value, source.Invert = strings.CutSuffix(value, "~")
+
+	if value == "" {
+		return Source{}, ErrInvalidMapping
+	}
+
+	switch value[0] {
+	case 'b':
+		source.Kind = SourceButton
+	case 'a':
+		source.Kind = SourceAxis
+	case 'h':
+		source.Kind = SourceHat
+
+		hat, mask, found = strings.Cut(value[1:], ".")
+		if !found {
+			return Source{}, ErrInvalidMapping
+		}
+
+		index, err = strconv.Atoi(hat)
+		if err != nil || index < 0 {
+			return Source{}, ErrInvalidMapping
+		}
+
+		bits, err = strconv.Atoi(mask)
+		if err != nil || bits <= 0 || bits > 15 {
+			return Source{}, ErrInvalidMapping
+		}
+
+		source.Index = index
+		source.HatMask = uint8(bits)
+
+		return source, nil
+	default:
+		return Source{}, ErrInvalidMapping
+	}
+
+	if source.Kind == SourceButton && (source.Half != Full || source.Invert) {
+		return Source{}, ErrInvalidMapping
+	}
+
+	index, err = strconv.Atoi(value[1:])
+	if err != nil || index < 0 {
+		return Source{}, ErrInvalidMapping
+	}
+
+	source.Index = index
+
+	return source, nil
+}
+
+func trimHalf(str string) (string, Half) {
+	switch {
+	case strings.HasPrefix(str, "+"):
+		return str[1:], Positive
+	case strings.HasPrefix(str, "-"):
+		return str[1:], Negative
+	}
+
+	return str, Full
+}
+
+func halfPrefix(half Half) string {
+	switch half {
+	case Positive:
+		return "+"
+	case Negative:
+		return "-"
+	}
+
+	return ""
+}
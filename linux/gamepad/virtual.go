@@ -0,0 +1,469 @@
+package gamepad
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"sync"
+	"time"
+
+	"github.com/andrieee44/gopkg/linux/uapi/input"
+	uapi "github.com/andrieee44/gopkg/linux/uapi/uinput"
+	"github.com/andrieee44/gopkg/linux/uinput"
+)
+
+// VirtualName is the name given to devices made by [NewVirtualDevice]
+// when [VirtualOptions].Name is empty. It is the name the kernel's xpad
+// driver gives wired Xbox 360 controllers.
+const VirtualName string = "Microsoft X-Box 360 pad"
+
+// VirtualEffects is the number of force feedback effects a device made
+// by [NewVirtualDevice] can store.
+const VirtualEffects uint32 = 16
+
+// VirtualID is the [input.ID] given to devices made by [NewVirtualDevice]
+// when [VirtualOptions].ID is nil: that of a wired Xbox 360 controller,
+// which games and SDL recognise without a mapping.
+var VirtualID input.ID = input.ID{
+	Bustype: input.BUS_USB,
+	Vendor:  0x045e,
+	Product: 0x028e,
+	Version: 0x0114,
+}
+
+// ErrUnsupportedEffect is returned by [Rumbler.Upload] for an effect other
+// than [input.FF_RUMBLE].
+var ErrUnsupportedEffect error = errors.New("unsupported force feedback effect")
+
+// ErrInvalidControl is returned by [Virtual.SetButton] and
+// [Virtual.SetAxis] for a button or axis outside the standard controls.
+var ErrInvalidControl error = errors.New("invalid gamepad control")
+
+// Rumble is a request to drive the rumble motors of a controller. The
+// zero Rumble stops them.
+type Rumble struct {
+	// Strong and Weak are the magnitudes of the heavy and light motors.
+	Strong, Weak uint16
+
+	// Delay is the time to wait before starting the motors.
+	Delay time.Duration
+
+	// Duration is how long the motors run for, or zero to run them
+	// until the next Rumble.
+	Duration time.Duration
+}
+
+// VirtualOptions configures a device made by [NewVirtualDevice].
+type VirtualOptions struct {
+	// Name is the device name. It defaults to [VirtualName].
+	Name string
+
+	// ID is the device identifier. It defaults to [VirtualID].
+	ID *input.ID
+
+	// OnRumble is called from the device's event loop whenever an
+	// application starts or stops a rumble effect. It may be nil.
+	OnRumble func(Rumble)
+}
+
+// Virtual drives a virtual controller made by [NewVirtualDevice] from
+// standard controls, emitting only the controls that changed. It is safe
+// for concurrent use.
+type Virtual struct {
+	emitter uinput.Emitter
+	state   State
+	mu      sync.Mutex
+}
+
+// Rumbler is a [uinput.FFHandler] storing the [input.FF_RUMBLE] effects
+// applications upload to a virtual controller and reporting them as a
+// [Rumble] when played. It is safe for concurrent use.
+type Rumbler struct {
+	effects map[int16]input.FFEffect
+	playing map[int16]rumblePlay
+	plays   uint64
+	report  func(Rumble)
+	mu      sync.Mutex
+}
+
+// rumblePlay is an effect a [Rumbler] has been asked to play.
+type rumblePlay struct {
+	// rumble is the request reported when the effect was played, at
+	// start, as the seq-th play.
+	rumble Rumble
+	start  time.Time
+	seq    uint64
+}
+
+// virtualButtons maps standard buttons to the keys reported by the xpad
+// driver, which swaps [input.BTN_NORTH] and [input.BTN_WEST] relative to
+// [DefaultMapping]. The d-pad is reported on the first hat instead.
+var virtualButtons map[Button]input.KeyCode = map[Button]input.KeyCode{
+	ButtonA:             input.BTN_SOUTH,
+	ButtonB:             input.BTN_EAST,
+	ButtonX:             input.BTN_NORTH,
+	ButtonY:             input.BTN_WEST,
+	ButtonBack:          input.BTN_SELECT,
+	ButtonGuide:         input.BTN_MODE,
+	ButtonStart:         input.BTN_START,
+	ButtonLeftStick:     input.BTN_THUMBL,
+	ButtonRightStick:    input.BTN_THUMBR,
+	ButtonLeftShoulder:  input.BTN_TL,
+	ButtonRightShoulder: input.BTN_TR,
+}
+
+// NewVirtualDevice creates a virtual Xbox 360 style controller through
+// uinput, with the buttons, sticks, triggers and d-pad hat of the xpad
+// driver and [input.FF_RUMBLE] force feedback. Rumble effects uploaded by
+// applications are kept by a [Rumbler] reporting to opts.OnRumble. Pass
+// the device to [NewVirtual], and close it with [uinput.Device.Close] when
+// done.
+func NewVirtualDevice(opts VirtualOptions) (*uinput.Device, error) {
+	var (
+		dev    *uinput.Device
+		id     input.ID
+		keys   []input.KeyCode
+		button Button
+		steps  []func() error
+		step   func() error
+		err    error
+	)
+
+	id = VirtualID
+	if opts.ID != nil {
+		id = *opts.ID
+	}
+
+	if opts.Name == "" {
+		opts.Name = VirtualName
+	}
+
+	dev, err = uinput.NewDevice(id, opts.Name)
+	if err != nil {
+		return nil, err
+	}
+
+	for button = range ButtonCount {
+		if virtualButtons[button] != 0 {
+			keys = append(keys, virtualButtons[button])
+		}
+	}
+
+	steps = []func() error{
+		func() error {
+			return dev.SetEvents([]input.EventCode{input.EV_KEY, input.EV_ABS, input.EV_FF})
+		},
+		func() error { return dev.SetKeys(keys) },
+		func() error { return dev.SetAbsInfos(virtualAbsInfos()) },
+		func() error { return dev.SetForceFeedbacks([]input.FFCode{input.FF_RUMBLE}) },
+		dev.Create,
+	}
+
+	dev.SetFFHandler(VirtualEffects, NewRumbler(opts.OnRumble))
+
+	for _, step = range steps {
+		err = step()
+		if err != nil {
+			return nil, errors.Join(err, dev.Close())
+		}
+	}
+
+	return dev, nil
+}
+
+// NewVirtual returns a [Virtual] emitting to emitter, which must support
+// the events of a device made by [NewVirtualDevice]. All controls start
+// released and centred.
+func NewVirtual(emitter uinput.Emitter) *Virtual {
+	return &Virtual{emitter: emitter}
+}
+
+// State returns the last state emitted.
+func (pad *Virtual) State() State {
+	pad.mu.Lock()
+	defer pad.mu.Unlock()
+
+	return pad.state
+}
+
+// Update emits the controls of state that differ from the last state in
+// a single frame. Axes are clamped to their range, and buttons the
+// device does not have, such as the paddles, are ignored.
+func (pad *Virtual) Update(state State) error {
+	pad.mu.Lock()
+	defer pad.mu.Unlock()
+
+	return pad.update(state)
+}
+
+// SetButton presses or releases button. It returns [ErrInvalidControl]
+// for a button not below [ButtonCount].
+func (pad *Virtual) SetButton(button Button, pressed bool) error {
+	var state State
+
+	if button >= ButtonCount {
+		return fmt.Errorf("failed to set %s: %w", button, ErrInvalidControl)
+	}
+
+	pad.mu.Lock()
+	defer pad.mu.Unlock()
+
+	state = pad.state
+	state.Buttons[button] = pressed
+
+	return pad.update(state)
+}
+
+// SetAxis moves ax to value, in [-1, 1] for sticks and [0, 1] for
+// triggers. It returns [ErrInvalidControl] for an axis not below
+// [AxisCount].
+func (pad *Virtual) SetAxis(ax Axis, value float64) error {
+	var state State
+
+	if ax >= AxisCount {
+		return fmt.Errorf("failed to set %s: %w", ax, ErrInvalidControl)
+	}
+
+	pad.mu.Lock()
+	defer pad.mu.Unlock()
+
+	state = pad.state
+	state.Axes[ax] = value
+
+	return pad.update(state)
+}
+
+// update emits the controls of state that differ from the last state. The
+// caller must hold pad.mu.
+func (pad *Virtual) update(state State) error {
+	var (
+		events []input.Event
+		button Button
+		ax     Axis
+		abs    input.AbsoluteCode
+		value  int32
+		err    error
+	)
+
+	for button = range ButtonCount {
+		if virtualButtons[button] == 0 || state.Buttons[button] == pad.state.Buttons[button] {
+			continue
+		}
+
+		events = append(events, uinput.PressEvent(virtualButtons[button], state.Buttons[button]))
+	}
+
+	for ax = range AxisCount {
+		abs = defaultAxes[ax]
+		value = virtualValue(ax, state.Axes[ax])
+
+		if value != virtualValue(ax, pad.state.Axes[ax]) {
+			events = append(events, uinput.AbsEvent(abs, value))
+		}
+	}
+
+	value = virtualHat(state, ButtonDPadLeft, ButtonDPadRight)
+	if value != virtualHat(pad.state, ButtonDPadLeft, ButtonDPadRight) {
+		events = append(events, uinput.AbsEvent(input.ABS_HAT0X, value))
+	}
+
+	value = virtualHat(state, ButtonDPadUp, ButtonDPadDown)
+	if value != virtualHat(pad.state, ButtonDPadUp, ButtonDPadDown) {
+		events = append(events, uinput.AbsEvent(input.ABS_HAT0Y, value))
+	}
+
+	if len(events) == 0 {
+		pad.state = state
+
+		return nil
+	}
+
+	err = pad.emitter.EmitBatch(append(events, uinput.SyncEvent()))
+	if err != nil {
+		return fmt.Errorf("failed to update virtual gamepad: %w", err)
+	}
+
+	pad.state = state
+
+	return nil
+}
+
+// NewRumbler returns a [Rumbler] reporting played effects to report,
+// which may be nil.
+func NewRumbler(report func(Rumble)) *Rumbler {
+	return &Rumbler{
+		effects: make(map[int16]input.FFEffect),
+		playing: make(map[int16]rumblePlay),
+		report:  report,
+	}
+}
+
+// Upload stores effect under its ID, replacing old. It returns
+// [ErrUnsupportedEffect] for effects other than [input.FF_RUMBLE].
+func (rumbler *Rumbler) Upload(effect, _ input.FFEffect) error {
+	if input.FFCode(effect.Type) != input.FF_RUMBLE {
+		return fmt.Errorf(
+			"failed to upload effect %d: %s: %w",
+			effect.ID,
+			input.FFCode(effect.Type),
+			ErrUnsupportedEffect,
+		)
+	}
+
+	rumbler.mu.Lock()
+	defer rumbler.mu.Unlock()
+
+	rumbler.effects[effect.ID] = effect
+
+	return nil
+}
+
+// Erase removes the effect with the given ID.
+func (rumbler *Rumbler) Erase(id int16) error {
+	rumbler.mu.Lock()
+	defer rumbler.mu.Unlock()
+
+	delete(rumbler.effects, id)
+	delete(rumbler.playing, id)
+
+	return nil
+}
+
+// Play reports the effect with the given ID played count times in a row.
+// When count is 0 the effect is stopped, and the last started of the
+// effects still running is reported for the time it has left, or the
+// zero [Rumble] if none is. Effects that have not been uploaded are
+// ignored.
+func (rumbler *Rumbler) Play(id int16, count int32) {
+	var (
+		effect input.FFEffect
+		rumble Rumble
+		params input.FFRumbleEffect
+		now    time.Time
+		ok     bool
+	)
+
+	rumbler.mu.Lock()
+
+	effect, ok = rumbler.effects[id]
+	if !ok {
+		rumbler.mu.Unlock()
+
+		return
+	}
+
+	now = time.Now()
+
+	if count > 0 {
+		params = effect.Effect.Rumble()
+		rumble = Rumble{
+			Strong:   params.StrongMagnitude,
+			Weak:     params.WeakMagnitude,
+			Delay:    time.Duration(effect.Replay.Delay) * time.Millisecond,
+			Duration: time.Duration(effect.Replay.Length) * time.Millisecond * time.Duration(count),
+		}
+		rumbler.plays++
+		rumbler.playing[id] = rumblePlay{rumble: rumble, start: now, seq: rumbler.plays}
+	} else {
+		delete(rumbler.playing, id)
+		rumble = rumbler.running(now)
+	}
+
+	rumbler.mu.Unlock()
+
+	if rumbler.report != nil {
+		rumbler.report(rumble)
+	}
+}
+
+// running forgets the effects that have finished by now and returns the
+// last started of the others, as a [Rumble] for the time it has left.
+func (rumbler *Rumbler) running(now time.Time) Rumble {
+	var (
+		id     int16
+		play   rumblePlay
+		last   rumblePlay
+		begin  time.Time
+		rumble Rumble
+	)
+
+	for id, play = range rumbler.playing {
+		begin = play.start.Add(play.rumble.Delay)
+
+		if play.rumble.Duration != 0 && !now.Before(begin.Add(play.rumble.Duration)) {
+			delete(rumbler.playing, id)
+
+			continue
+		}
+
+		if play.seq > last.seq {
+			last = play
+		}
+	}
+
+	if last.seq == 0 {
+		return Rumble{}
+	}
+
+	rumble = last.rumble
+	begin = last.start.Add(rumble.Delay)
+
+	if begin.After(now) {
+		rumble.Delay = begin.Sub(now)
+
+		return rumble
+	}
+
+	rumble.Delay = 0
+
+	if rumble.Duration != 0 {
+		rumble.Duration = begin.Add(rumble.Duration).Sub(now)
+	}
+
+	return rumble
+}
+
+func virtualAbsInfos() []uapi.AbsSetup {
+	var stick, trigger, hat input.AbsInfo
+
+	stick = input.AbsInfo{Minimum: math.MinInt16, Maximum: math.MaxInt16, Fuzz: 16, Flat: 128}
+	trigger = input.AbsInfo{Maximum: math.MaxUint8}
+	hat = input.AbsInfo{Minimum: -1, Maximum: 1}
+
+	return []uapi.AbsSetup{
+		{Code: input.ABS_X, AbsInfo: stick},
+		{Code: input.ABS_Y, AbsInfo: stick},
+		{Code: input.ABS_Z, AbsInfo: trigger},
+		{Code: input.ABS_RX, AbsInfo: stick},
+		{Code: input.ABS_RY, AbsInfo: stick},
+		{Code: input.ABS_RZ, AbsInfo: trigger},
+		{Code: input.ABS_HAT0X, AbsInfo: hat},
+		{Code: input.ABS_HAT0Y, AbsInfo: hat},
+	}
+}
+
+// virtualValue converts the position of ax to its raw value on a device
+// made by [NewVirtualDevice].
+func virtualValue(ax Axis, value float64) int32 {
+	if ax.IsTrigger() {
+		return int32(math.Round(min(max(value, 0), 1) * math.MaxUint8))
+	}
+
+	return int32(math.Round(min(max(value, -1), 1) * math.MaxInt16))
+}
+
+// virtualHat returns the hat value of a d-pad axis: -1 if only negative
+// is pressed, 1 if only positive is, and 0 otherwise.
+func virtualHat(state State, negative, positive Button) int32 {
+	var value int32
+
+	if state.Buttons[negative] {
+		value--
+	}
+
+	if state.Buttons[positive] {
+		value++
+	}
+
+	return value
+}
@@ -0,0 +1,233 @@
+package gamepad_test
+
+import (
+	"errors"
+	"math"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/andrieee44/gopkg/linux/gamepad"
+	"github.com/andrieee44/gopkg/linux/uapi/input"
+	"github.com/andrieee44/gopkg/linux/uinput/uinputtest"
+)
+
+func TestVirtual(t *testing.T) {
+	var (
+		rec      *uinputtest.Recorder
+		virtual  *gamepad.Virtual
+		mapping  *gamepad.Mapping
+		pad      *gamepad.Gamepad
+		state    gamepad.State
+		event    input.Event
+		ax       gamepad.Axis
+		err      error
+		expState gamepad.State
+	)
+
+	t.Parallel()
+
+	rec = new(uinputtest.Recorder)
+	virtual = gamepad.NewVirtual(rec)
+
+	state.Buttons[gamepad.ButtonX] = true
+	state.Buttons[gamepad.ButtonDPadLeft] = true
+	state.Axes[gamepad.AxisLeftY] = -1
+	state.Axes[gamepad.AxisRightTrigger] = 2
+
+	err = virtual.Update(state)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(rec.Events()) != 5 {
+		t.Errorf("events: got: %d, exp: 5", len(rec.Events()))
+	}
+
+	err = virtual.Update(state)
+	if err != nil || len(rec.Events()) != 5 {
+		t.Errorf("unchanged: got: %d events, %v, exp: 5 events, nil", len(rec.Events()), err)
+	}
+
+	mapping, err = gamepad.ParseMapping(xbox360)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	pad = gamepad.NewGamepad(mapping, xpadSnapshot())
+
+	for _, event = range rec.Events() {
+		pad.Update(event)
+	}
+
+	expState = state
+	expState.Axes[gamepad.AxisRightTrigger] = 1
+
+	if pad.State().Buttons != expState.Buttons {
+		t.Errorf("buttons: got: %v, exp: %v", pad.State().Buttons, expState.Buttons)
+	}
+
+	for ax = range gamepad.AxisCount {
+		if math.Abs(pad.State().Axes[ax]-expState.Axes[ax]) > 1e-3 {
+			t.Errorf("%s: got: %v, exp: %v", ax, pad.State().Axes[ax], expState.Axes[ax])
+		}
+	}
+}
+
+func TestVirtualInvalidControl(t *testing.T) {
+	var (
+		rec *uinputtest.Recorder
+		pad *gamepad.Virtual
+		err error
+	)
+
+	t.Parallel()
+
+	rec = new(uinputtest.Recorder)
+	pad = gamepad.NewVirtual(rec)
+
+	err = pad.SetButton(gamepad.ButtonCount, true)
+	if !errors.Is(err, gamepad.ErrInvalidControl) {
+		t.Errorf("button: got: %v, exp: %v", err, gamepad.ErrInvalidControl)
+	}
+
+	err = pad.SetAxis(gamepad.AxisCount, 1)
+	if !errors.Is(err, gamepad.ErrInvalidControl) {
+		t.Errorf("axis: got: %v, exp: %v", err, gamepad.ErrInvalidControl)
+	}
+
+	if len(rec.Events()) != 0 {
+		t.Errorf("events: got: %v, exp: none", rec.Events())
+	}
+}
+
+func TestVirtualConcurrent(t *testing.T) {
+	var (
+		rec    *uinputtest.Recorder
+		pad    *gamepad.Virtual
+		wg     sync.WaitGroup
+		button gamepad.Button
+		exp    gamepad.State
+	)
+
+	t.Parallel()
+
+	rec = new(uinputtest.Recorder)
+	pad = gamepad.NewVirtual(rec)
+
+	for button = range gamepad.ButtonCount {
+		exp.Buttons[button] = true
+
+		wg.Add(1)
+
+		go func(button gamepad.Button) {
+			defer wg.Done()
+
+			_ = pad.SetButton(button, true)
+		}(button)
+	}
+
+	wg.Wait()
+
+	if pad.State() != exp {
+		t.Errorf("got: %v, exp: %v", pad.State().Buttons, exp.Buttons)
+	}
+}
+
+func TestRumbler(t *testing.T) {
+	var (
+		rumbler *gamepad.Rumbler
+		got     []gamepad.Rumble
+		exp     []gamepad.Rumble
+		effect  input.FFEffect
+		err     error
+	)
+
+	t.Parallel()
+
+	rumbler = gamepad.NewRumbler(func(rumble gamepad.Rumble) { got = append(got, rumble) })
+
+	effect = input.FFEffect{
+		Type:   uint16(input.FF_RUMBLE),
+		ID:     3,
+		Replay: input.FFReplay{Length: 250, Delay: 10},
+		Effect: input.NewFFRumbleUnion(input.FFRumbleEffect{
+			StrongMagnitude: 0x8000,
+			WeakMagnitude:   0x1000,
+		}),
+	}
+
+	err = rumbler.Upload(effect, input.FFEffect{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	rumbler.Play(3, 2)
+	rumbler.Play(3, 0)
+
+	exp = []gamepad.Rumble{
+		{Strong: 0x8000, Weak: 0x1000, Delay: 10 * time.Millisecond, Duration: 500 * time.Millisecond},
+		{},
+	}
+
+	if len(got) != len(exp) || got[0] != exp[0] || got[1] != exp[1] {
+		t.Errorf("got: %+v, exp: %+v", got, exp)
+	}
+
+	err = rumbler.Erase(3)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	rumbler.Play(3, 1)
+
+	if len(got) != len(exp) {
+		t.Errorf("erased: got: %+v, exp: %+v", got, exp)
+	}
+
+	effect.Type = uint16(input.FF_PERIODIC)
+
+	err = rumbler.Upload(effect, input.FFEffect{})
+	if !errors.Is(err, gamepad.ErrUnsupportedEffect) {
+		t.Errorf("periodic: got: %v, exp: %v", err, gamepad.ErrUnsupportedEffect)
+	}
+}
+
+func TestRumblerOverlap(t *testing.T) {
+	var (
+		rumbler *gamepad.Rumbler
+		got     []gamepad.Rumble
+		exp     []gamepad.Rumble
+		id      int16
+		err     error
+	)
+
+	t.Parallel()
+
+	rumbler = gamepad.NewRumbler(func(rumble gamepad.Rumble) { got = append(got, rumble) })
+
+	for id = range 2 {
+		err = rumbler.Upload(input.FFEffect{
+			Type: uint16(input.FF_RUMBLE),
+			ID:   id,
+			Effect: input.NewFFRumbleUnion(input.FFRumbleEffect{
+				StrongMagnitude: uint16(id+1) * 0x1000,
+			}),
+		}, input.FFEffect{})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	rumbler.Play(0, 1)
+	rumbler.Play(1, 1)
+	rumbler.Play(1, 0)
+	rumbler.Play(0, 0)
+
+	exp = []gamepad.Rumble{{Strong: 0x1000}, {Strong: 0x2000}, {Strong: 0x1000}, {}}
+
+	if !slices.Equal(got, exp) {
+		t.Errorf("got: %+v, exp: %+v", got, exp)
+	}
+}
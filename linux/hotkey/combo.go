@@ -0,0 +1,339 @@
+package hotkey
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/andrieee44/gopkg/linux/uapi/input"
+)
+
+// Modifiers is a bitmask of modifier keys. Each physical modifier key has
+// its own bit; the side-agnostic masks such as [ModCtrl] cover both sides
+// and match whichever side is held.
+type Modifiers uint8
+
+const (
+	// ModLeftCtrl is the bit for [input.KEY_LEFTCTRL].
+	ModLeftCtrl Modifiers = 1 << iota
+
+	// ModRightCtrl is the bit for [input.KEY_RIGHTCTRL].
+	ModRightCtrl
+
+	// ModLeftShift is the bit for [input.KEY_LEFTSHIFT].
+	ModLeftShift
+
+	// ModRightShift is the bit for [input.KEY_RIGHTSHIFT].
+	ModRightShift
+
+	// ModLeftAlt is the bit for [input.KEY_LEFTALT].
+	ModLeftAlt
+
+	// ModRightAlt is the bit for [input.KEY_RIGHTALT].
+	ModRightAlt
+
+	// ModLeftMeta is the bit for [input.KEY_LEFTMETA].
+	ModLeftMeta
+
+	// ModRightMeta is the bit for [input.KEY_RIGHTMETA].
+	ModRightMeta
+)
+
+const (
+	// ModCtrl matches either control key.
+	ModCtrl = ModLeftCtrl | ModRightCtrl
+
+	// ModShift matches either shift key.
+	ModShift = ModLeftShift | ModRightShift
+
+	// ModAlt matches either alt key.
+	ModAlt = ModLeftAlt | ModRightAlt
+
+	// ModMeta matches either meta (super, logo) key.
+	ModMeta = ModLeftMeta | ModRightMeta
+)
+
+// MatchMode selects how strictly the held modifiers must agree with the
+// modifiers of a [Combo].
+type MatchMode uint8
+
+const (
+	// MatchExact requires the held modifiers to be exactly those of the
+	// combo. A side-agnostic modifier such as [ModCtrl] is satisfied by
+	// either side or both.
+	MatchExact MatchMode = iota
+
+	// MatchSubset requires the combo's modifiers to be held but allows
+	// any additional modifiers.
+	MatchSubset
+)
+
+// ErrInvalidCombo is returned when a combination string cannot be parsed.
+var ErrInvalidCombo error = errors.New("invalid key combination")
+
+var (
+	modifierGroups = []Modifiers{ModCtrl, ModShift, ModAlt, ModMeta}
+
+	modifierOrder = []input.KeyCode{
+		input.KEY_LEFTCTRL,
+		input.KEY_RIGHTCTRL,
+		input.KEY_LEFTSHIFT,
+		input.KEY_RIGHTSHIFT,
+		input.KEY_LEFTALT,
+		input.KEY_RIGHTALT,
+		input.KEY_LEFTMETA,
+		input.KEY_RIGHTMETA,
+	}
+
+	modifierKeys = map[input.KeyCode]Modifiers{
+		input.KEY_LEFTCTRL:   ModLeftCtrl,
+		input.KEY_RIGHTCTRL:  ModRightCtrl,
+		input.KEY_LEFTSHIFT:  ModLeftShift,
+		input.KEY_RIGHTSHIFT: ModRightShift,
+		input.KEY_LEFTALT:    ModLeftAlt,
+		input.KEY_RIGHTALT:   ModRightAlt,
+		input.KEY_LEFTMETA:   ModLeftMeta,
+		input.KEY_RIGHTMETA:  ModRightMeta,
+	}
+
+	modifierAliases = map[string]Modifiers{
+		"CTRL":    ModCtrl,
+		"CONTROL": ModCtrl,
+		"SHIFT":   ModShift,
+		"ALT":     ModAlt,
+		"META":    ModMeta,
+		"SUPER":   ModMeta,
+	}
+
+	modifierNames = map[Modifiers]string{
+		ModCtrl:  "CTRL",
+		ModShift: "SHIFT",
+		ModAlt:   "ALT",
+		ModMeta:  "META",
+	}
+)
+
+// Combo is a key pressed while a set of modifiers is held, such as
+// KEY_LEFTMETA+KEY_ENTER.
+type Combo struct {
+	// Modifiers lists the modifier keys that must be held when Key is
+	// pressed.
+	Modifiers Modifiers
+
+	// Key is the key whose press triggers the combination.
+	Key input.KeyCode
+}
+
+// Sequence is a series of combinations that must be pressed one after
+// another, such as a leader key followed by a command key.
+type Sequence []Combo
+
+// ModifierOf returns the modifier bit for key, or zero if key is not a
+// modifier key.
+func ModifierOf(key input.KeyCode) Modifiers {
+	return modifierKeys[key]
+}
+
+// String returns the modifiers joined by "+", using the side-agnostic
+// aliases CTRL, SHIFT, ALT and META when both sides are set and the
+// [input.KeyCode] names otherwise.
+func (mods Modifiers) String() string {
+	var (
+		names []string
+		group Modifiers
+		key   input.KeyCode
+	)
+
+	for _, group = range modifierGroups {
+		if mods&group == group {
+			names = append(names, modifierNames[group])
+
+			continue
+		}
+
+		for _, key = range modifierOrder {
+			if mods&group&modifierKeys[key] != 0 {
+				names = append(names, key.String())
+			}
+		}
+	}
+
+	return strings.Join(names, "+")
+}
+
+// ParseCombo parses a combination such as "KEY_LEFTMETA+KEY_ENTER" or
+// "ctrl+alt+KEY_T". Elements are separated by "+" and the last element is
+// the trigger key. Keys are looked up in the [input.KeyCode] name table,
+// case-insensitively and with an optional "KEY_" prefix, so "enter" and
+// "KEY_ENTER" are equivalent. The aliases CTRL, CONTROL, SHIFT, ALT, META
+// and SUPER stand for either side of a modifier. Returns an error wrapping
+// [ErrInvalidCombo] if spec cannot be parsed.
+func ParseCombo(spec string) (Combo, error) {
+	var (
+		combo  Combo
+		fields []string
+		field  string
+		key    input.KeyCode
+		mods   Modifiers
+		idx    int
+		ok     bool
+		err    error
+	)
+
+	fields = strings.Split(spec, "+")
+
+	for idx, field = range fields {
+		field = strings.TrimSpace(field)
+		if field == "" {
+			return Combo{}, fmt.Errorf("%q: empty element: %w", spec, ErrInvalidCombo)
+		}
+
+		mods, ok = modifierAliases[strings.ToUpper(field)]
+		if ok {
+			if idx == len(fields)-1 {
+				return Combo{}, fmt.Errorf(
+					"%q: modifier alias %s cannot be the trigger key: %w",
+					spec,
+					field,
+					ErrInvalidCombo,
+				)
+			}
+
+			combo.Modifiers |= mods
+
+			continue
+		}
+
+		key, err = parseKey(field)
+		if err != nil {
+			return Combo{}, fmt.Errorf("%q: %w", spec, err)
+		}
+
+		if idx == len(fields)-1 {
+			combo.Key = key
+
+			continue
+		}
+
+		mods = ModifierOf(key)
+		if mods == 0 {
+			return Combo{}, fmt.Errorf(
+				"%q: %s is not a modifier key: %w",
+				spec,
+				key.String(),
+				ErrInvalidCombo,
+			)
+		}
+
+		combo.Modifiers |= mods
+	}
+
+	return combo, nil
+}
+
+// String returns the combination in the form accepted by [ParseCombo].
+func (combo Combo) String() string {
+	if combo.Modifiers == 0 {
+		return combo.Key.String()
+	}
+
+	return combo.Modifiers.String() + "+" + combo.Key.String()
+}
+
+// Matches reports whether held satisfies the combination's modifiers
+// under mode. The bit of the combination's own trigger key, if it is a
+// modifier, is ignored.
+func (combo Combo) Matches(held Modifiers, mode MatchMode) bool {
+	var want, have, group Modifiers
+
+	held &^= ModifierOf(combo.Key)
+
+	for _, group = range modifierGroups {
+		want = combo.Modifiers & group
+		have = held & group
+
+		if want == 0 {
+			if mode == MatchExact && have != 0 {
+				return false
+			}
+
+			continue
+		}
+
+		if have&want == 0 {
+			return false
+		}
+
+		if mode == MatchExact && have&^want != 0 {
+			return false
+		}
+	}
+
+	return true
+}
+
+// ParseSequence parses whitespace-separated combinations, such as
+// "KEY_LEFTMETA+KEY_SPACE KEY_T", into a [Sequence]. Each element is
+// parsed with [ParseCombo].
+func ParseSequence(spec string) (Sequence, error) {
+	var (
+		seq    Sequence
+		fields []string
+		field  string
+		combo  Combo
+		err    error
+	)
+
+	fields = strings.Fields(spec)
+	if len(fields) == 0 {
+		return nil, fmt.Errorf("%q: empty sequence: %w", spec, ErrInvalidCombo)
+	}
+
+	seq = make(Sequence, 0, len(fields))
+
+	for _, field = range fields {
+		combo, err = ParseCombo(field)
+		if err != nil {
+			return nil, err
+		}
+
+		seq = append(seq, combo)
+	}
+
+	return seq, nil
+}
+
+// String returns the sequence in the form accepted by [ParseSequence].
+func (seq Sequence) String() string {
+	var (
+		names []string
+		combo Combo
+	)
+
+	names = make([]string, 0, len(seq))
+
+	for _, combo = range seq {
+		names = append(names, combo.String())
+	}
+
+	return strings.Join(names, " ")
+}
+
+func parseKey(name string) (input.KeyCode, error) {
+	var (
+		key input.KeyCode
+		err error
+	)
+
+	key, err = input.KeyCodeString(name)
+	if err == nil {
+		return key, nil
+	}
+
+	key, err = input.KeyCodeString("KEY_" + name)
+	if err == nil {
+		return key, nil
+	}
+
+	return 0, fmt.Errorf("unknown key %s: %w", name, ErrInvalidCombo)
+}
@@ -0,0 +1,450 @@
+// Package hotkey detects global hotkeys by watching key events read
+// directly from evdev devices. Because it never talks to a display server,
+// it works on a TTY, in a headless kiosk, or under any compositor.
+//
+// A [Registry] holds bindings of three kinds: combinations such as
+// KEY_LEFTMETA+KEY_ENTER, sequences started by a leader key, and
+// long-presses that fire only after a combination has been held for a
+// duration. Combinations are parsed from strings with [ParseCombo] and
+// [ParseSequence] using the [input.KeyCode] name tables.
+package hotkey
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/andrieee44/gopkg/linux/evdev"
+	"github.com/andrieee44/gopkg/linux/internal/evdevmux"
+	"github.com/andrieee44/gopkg/linux/uapi/input"
+)
+
+// DefaultSequenceTimeout is the default maximum delay between two steps
+// of a [Sequence] before the sequence is abandoned.
+const DefaultSequenceTimeout = time.Second
+
+// ErrInvalidBinding is returned by [Registry.Add] when a [Binding] has no
+// steps or no callback.
+var ErrInvalidBinding error = errors.New("invalid hotkey binding")
+
+// Callback is invoked when a binding fires. event is the key press that
+// completed the binding; for long-press bindings it is the press that
+// started the hold.
+type Callback func(event input.Event)
+
+// Binding describes a hotkey and the callback it fires.
+type Binding struct {
+	// Sequence lists the combinations that must be pressed in order. A
+	// single-element sequence is a plain combination.
+	Sequence Sequence
+
+	// Match selects how strictly held modifiers must agree with each
+	// step of Sequence.
+	Match MatchMode
+
+	// Hold, when positive, turns the binding into a long-press: the last
+	// step must be held for Hold before Callback fires. Releasing the
+	// trigger key or one of its modifiers earlier cancels it.
+	Hold time.Duration
+
+	// Callback is invoked when the binding fires.
+	Callback Callback
+}
+
+// Registry tracks key state across one or more devices and fires the
+// callbacks of registered bindings. The zero value is not usable; create
+// one with [NewRegistry]. A Registry is safe for concurrent use, and
+// callbacks are invoked without holding its lock, so they may add new
+// bindings.
+type Registry struct {
+	mu       sync.Mutex
+	bindings []*binding
+	held     map[int]map[input.KeyCode]bool
+	timeout  time.Duration
+}
+
+type binding struct {
+	Binding
+
+	step         int
+	stepDeadline time.Time
+	holdEvent    input.Event
+	holdDeadline time.Time
+}
+
+type firing struct {
+	callback Callback
+	event    input.Event
+}
+
+// NewRegistry returns an empty [Registry] using
+// [DefaultSequenceTimeout].
+func NewRegistry() *Registry {
+	return &Registry{
+		held:    make(map[int]map[input.KeyCode]bool),
+		timeout: DefaultSequenceTimeout,
+	}
+}
+
+// SetSequenceTimeout sets the maximum delay allowed between two steps of
+// a sequence.
+func (reg *Registry) SetSequenceTimeout(timeout time.Duration) {
+	reg.mu.Lock()
+	defer reg.mu.Unlock()
+
+	reg.timeout = timeout
+}
+
+// Add registers binding. It returns an error wrapping [ErrInvalidBinding]
+// if the binding has no steps or no callback.
+func (reg *Registry) Add(binding Binding) error {
+	if len(binding.Sequence) == 0 {
+		return fmt.Errorf("failed to add hotkey: empty sequence: %w", ErrInvalidBinding)
+	}
+
+	if binding.Callback == nil {
+		return fmt.Errorf(
+			"failed to add hotkey %s: nil callback: %w",
+			binding.Sequence.String(),
+			ErrInvalidBinding,
+		)
+	}
+
+	reg.mu.Lock()
+	defer reg.mu.Unlock()
+
+	reg.bindings = append(reg.bindings, newBinding(binding))
+
+	return nil
+}
+
+// Register parses spec with [ParseSequence] and registers it with exact
+// modifier matching.
+func (reg *Registry) Register(spec string, callback Callback) error {
+	return reg.RegisterHold(spec, 0, callback)
+}
+
+// RegisterHold parses spec with [ParseSequence] and registers it as a
+// long-press binding that fires after the last step is held for hold. A
+// zero hold registers an ordinary binding.
+func (reg *Registry) RegisterHold(spec string, hold time.Duration, callback Callback) error {
+	var (
+		seq Sequence
+		err error
+	)
+
+	seq, err = ParseSequence(spec)
+	if err != nil {
+		return fmt.Errorf("failed to register hotkey: %w", err)
+	}
+
+	return reg.Add(Binding{
+		Sequence: seq,
+		Match:    MatchExact,
+		Hold:     hold,
+		Callback: callback,
+	})
+}
+
+// Held returns the modifiers currently held across all watched devices.
+func (reg *Registry) Held() Modifiers {
+	reg.mu.Lock()
+	defer reg.mu.Unlock()
+
+	return reg.modifiers()
+}
+
+// Feed processes a single event read from source, a number identifying
+// the device, observed at now and invokes the callbacks of any bindings
+// it completes. Only [input.EV_KEY] events and [input.SYN_DROPPED] are
+// considered; after a dropped report the keys held on source and all
+// partial sequences are discarded. Autorepeat events are ignored. Feed is
+// called by [Registry.Run] and is exported so that callers with their own
+// event loop can drive the registry.
+func (reg *Registry) Feed(source int, event input.Event, now time.Time) {
+	var fired []firing
+
+	reg.mu.Lock()
+
+	switch {
+	case event.Type == input.EV_SYN && input.SyncCode(event.Code) == input.SYN_DROPPED:
+		reg.reset(source)
+	case event.Type != input.EV_KEY:
+	case event.Value == 1:
+		fired = reg.press(source, event, now)
+	case event.Value == 0:
+		reg.release(source, input.KeyCode(event.Code))
+	}
+
+	reg.mu.Unlock()
+
+	fire(fired)
+}
+
+// Tick fires long-press bindings whose hold duration has elapsed by now
+// and abandons sequences whose step timeout has passed.
+func (reg *Registry) Tick(now time.Time) {
+	var (
+		fired []firing
+		bind  *binding
+	)
+
+	reg.mu.Lock()
+
+	for _, bind = range reg.bindings {
+		if !bind.stepDeadline.IsZero() && !now.Before(bind.stepDeadline) {
+			bind.step = 0
+			bind.stepDeadline = time.Time{}
+		}
+
+		if bind.holdDeadline.IsZero() || now.Before(bind.holdDeadline) {
+			continue
+		}
+
+		fired = append(fired, firing{bind.Callback, bind.holdEvent})
+		bind.holdDeadline = time.Time{}
+	}
+
+	reg.mu.Unlock()
+
+	fire(fired)
+}
+
+// Deadline returns the earliest time at which [Registry.Tick] has work to
+// do, and false if no long-press or sequence is pending.
+func (reg *Registry) Deadline() (time.Time, bool) {
+	var (
+		deadline time.Time
+		bind     *binding
+	)
+
+	reg.mu.Lock()
+	defer reg.mu.Unlock()
+
+	for _, bind = range reg.bindings {
+		deadline = earliest(deadline, bind.stepDeadline)
+		deadline = earliest(deadline, bind.holdDeadline)
+	}
+
+	return deadline, !deadline.IsZero()
+}
+
+// Run reads events from devices and feeds them to the registry until ctx
+// is cancelled or a device fails. It starts [evdev.Device.ReadEvents] on
+// every device, so the devices must not be read elsewhere. Run returns
+// ctx's error on cancellation, or an error wrapping the device's read
+// error; a device reaching end of file is reported as [io.EOF]. Device i
+// is fed as source i. Callbacks run on the goroutine calling Run.
+func (reg *Registry) Run(ctx context.Context, devices ...*evdev.Device) error {
+	var (
+		cancel   context.CancelFunc
+		events   <-chan evdevmux.Event
+		errs     <-chan error
+		timer    *time.Timer
+		deadline time.Time
+		event    evdevmux.Event
+		pending  bool
+		err      error
+	)
+
+	ctx, cancel = context.WithCancel(ctx)
+	defer cancel()
+
+	events, errs = evdevmux.Read(ctx, devices, "failed to read hotkey events")
+
+	timer = time.NewTimer(0)
+	timer.Stop()
+
+	for {
+		deadline, pending = reg.Deadline()
+		if pending {
+			timer.Reset(time.Until(deadline))
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case err = <-errs:
+			return err
+		case event = <-events:
+			reg.Feed(event.Source, event.Event, time.Now())
+		case <-timer.C:
+			reg.Tick(time.Now())
+		}
+
+		timer.Stop()
+	}
+}
+
+func newBinding(bind Binding) *binding {
+	bind.Sequence = append(Sequence(nil), bind.Sequence...)
+
+	return &binding{Binding: bind}
+}
+
+func (reg *Registry) modifiers() Modifiers {
+	var (
+		mods Modifiers
+		keys map[input.KeyCode]bool
+		key  input.KeyCode
+	)
+
+	for _, keys = range reg.held {
+		for key = range keys {
+			mods |= ModifierOf(key)
+		}
+	}
+
+	return mods
+}
+
+// isHeld reports whether key is held on any source.
+func (reg *Registry) isHeld(key input.KeyCode) bool {
+	var keys map[input.KeyCode]bool
+
+	for _, keys = range reg.held {
+		if keys[key] {
+			return true
+		}
+	}
+
+	return false
+}
+
+// reset forgets the keys held on source and abandons partial sequences
+// and long-presses.
+func (reg *Registry) reset(source int) {
+	var bind *binding
+
+	delete(reg.held, source)
+
+	for _, bind = range reg.bindings {
+		bind.step = 0
+		bind.stepDeadline = time.Time{}
+		bind.holdDeadline = time.Time{}
+	}
+}
+
+func (reg *Registry) press(source int, event input.Event, now time.Time) []firing {
+	var (
+		fired []firing
+		bind  *binding
+		key   input.KeyCode
+		mods  Modifiers
+	)
+
+	key = input.KeyCode(event.Code)
+	mods = reg.modifiers()
+
+	if reg.held[source] == nil {
+		reg.held[source] = make(map[input.KeyCode]bool)
+	}
+
+	reg.held[source][key] = true
+
+	for _, bind = range reg.bindings {
+		if !bind.advance(key, mods, now, reg.timeout) {
+			continue
+		}
+
+		if bind.Hold > 0 {
+			bind.holdEvent = event
+			bind.holdDeadline = now.Add(bind.Hold)
+
+			continue
+		}
+
+		fired = append(fired, firing{bind.Callback, event})
+	}
+
+	return fired
+}
+
+func (reg *Registry) release(source int, key input.KeyCode) {
+	var (
+		bind *binding
+		last Combo
+	)
+
+	delete(reg.held[source], key)
+
+	if reg.isHeld(key) {
+		return
+	}
+
+	for _, bind = range reg.bindings {
+		if bind.holdDeadline.IsZero() {
+			continue
+		}
+
+		last = bind.Sequence[len(bind.Sequence)-1]
+		if key == last.Key || last.Modifiers&ModifierOf(key) != 0 {
+			bind.holdDeadline = time.Time{}
+		}
+	}
+}
+
+// advance moves bind forward on a press of key with mods held and reports
+// whether the press completed the binding's sequence.
+func (bind *binding) advance(
+	key input.KeyCode,
+	mods Modifiers,
+	now time.Time,
+	timeout time.Duration,
+) bool {
+	var combo Combo
+
+	if !bind.stepDeadline.IsZero() && !now.Before(bind.stepDeadline) {
+		bind.step = 0
+	}
+
+	combo = bind.Sequence[bind.step]
+	if combo.Key != key && ModifierOf(key) != 0 {
+		return false
+	}
+
+	if combo.Key != key || !combo.Matches(mods, bind.Match) {
+		bind.step = 0
+		combo = bind.Sequence[0]
+
+		if combo.Key != key || !combo.Matches(mods, bind.Match) {
+			bind.stepDeadline = time.Time{}
+
+			return false
+		}
+	}
+
+	bind.step++
+	if bind.step < len(bind.Sequence) {
+		bind.stepDeadline = now.Add(timeout)
+
+		return false
+	}
+
+	bind.step = 0
+	bind.stepDeadline = time.Time{}
+
+	return true
+}
+
+func fire(fired []firing) {
+	var f firing
+
+	for _, f = range fired {
+		f.callback(f.event)
+	}
+}
+
+func earliest(current, candidate time.Time) time.Time {
+	if candidate.IsZero() {
+		return current
+	}
+
+	if current.IsZero() || candidate.Before(current) {
+		return candidate
+	}
+
+	return current
+}
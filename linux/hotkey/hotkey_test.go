@@ -0,0 +1,236 @@
+package hotkey_test
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/andrieee44/gopkg/linux/hotkey"
+	"github.com/andrieee44/gopkg/linux/uapi/input"
+)
+
+func TestParseCombo(t *testing.T) {
+	type table struct {
+		spec string
+		exp  hotkey.Combo
+		str  string
+	}
+
+	var (
+		tests []table
+		test  table
+		combo hotkey.Combo
+		err   error
+	)
+
+	t.Parallel()
+
+	tests = []table{
+		{"KEY_ENTER", hotkey.Combo{Key: input.KEY_ENTER}, "KEY_ENTER"},
+		{"enter", hotkey.Combo{Key: input.KEY_ENTER}, "KEY_ENTER"},
+		{
+			"KEY_LEFTMETA+KEY_ENTER",
+			hotkey.Combo{Modifiers: hotkey.ModLeftMeta, Key: input.KEY_ENTER},
+			"KEY_LEFTMETA+KEY_ENTER",
+		},
+		{
+			"ctrl + alt + t",
+			hotkey.Combo{Modifiers: hotkey.ModCtrl | hotkey.ModAlt, Key: input.KEY_T},
+			"CTRL+ALT+KEY_T",
+		},
+		{
+			"SUPER+KEY_RIGHTSHIFT+f1",
+			hotkey.Combo{
+				Modifiers: hotkey.ModMeta | hotkey.ModRightShift,
+				Key:       input.KEY_F1,
+			},
+			"KEY_RIGHTSHIFT+META+KEY_F1",
+		},
+	}
+
+	for _, test = range tests {
+		combo, err = hotkey.ParseCombo(test.spec)
+		if err != nil {
+			t.Errorf("%q: %s", test.spec, err)
+
+			continue
+		}
+
+		if combo != test.exp {
+			t.Errorf("%q: got: %+v, exp: %+v", test.spec, combo, test.exp)
+		}
+
+		if combo.String() != test.str {
+			t.Errorf("%q: got: %q, exp: %q", test.spec, combo.String(), test.str)
+		}
+	}
+}
+
+func TestParseComboInvalid(t *testing.T) {
+	var (
+		specs []string
+		spec  string
+		err   error
+	)
+
+	t.Parallel()
+
+	specs = []string{"", "+", "ctrl", "KEY_A+KEY_B", "KEY_NOPE", "ctrl++a"}
+
+	for _, spec = range specs {
+		_, err = hotkey.ParseCombo(spec)
+		if !errors.Is(err, hotkey.ErrInvalidCombo) {
+			t.Errorf("%q: got: %v, exp: %v", spec, err, hotkey.ErrInvalidCombo)
+		}
+	}
+}
+
+func TestMatches(t *testing.T) {
+	type table struct {
+		spec string
+		held hotkey.Modifiers
+		mode hotkey.MatchMode
+		exp  bool
+	}
+
+	var (
+		tests []table
+		test  table
+		combo hotkey.Combo
+		err   error
+	)
+
+	t.Parallel()
+
+	tests = []table{
+		{"ctrl+a", hotkey.ModLeftCtrl, hotkey.MatchExact, true},
+		{"ctrl+a", hotkey.ModRightCtrl, hotkey.MatchExact, true},
+		{"ctrl+a", hotkey.ModCtrl, hotkey.MatchExact, true},
+		{"ctrl+a", 0, hotkey.MatchExact, false},
+		{"ctrl+a", hotkey.ModLeftCtrl | hotkey.ModLeftShift, hotkey.MatchExact, false},
+		{"ctrl+a", hotkey.ModLeftCtrl | hotkey.ModLeftShift, hotkey.MatchSubset, true},
+		{"KEY_LEFTCTRL+a", hotkey.ModRightCtrl, hotkey.MatchExact, false},
+		{"KEY_LEFTCTRL+a", hotkey.ModCtrl, hotkey.MatchExact, false},
+		{"KEY_LEFTCTRL+a", hotkey.ModCtrl, hotkey.MatchSubset, true},
+		{"a", hotkey.ModLeftAlt, hotkey.MatchExact, false},
+		{"a", hotkey.ModLeftAlt, hotkey.MatchSubset, true},
+		{"KEY_LEFTCTRL+KEY_LEFTSHIFT", hotkey.ModLeftCtrl | hotkey.ModLeftShift, hotkey.MatchExact, true},
+	}
+
+	for _, test = range tests {
+		combo, err = hotkey.ParseCombo(test.spec)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if combo.Matches(test.held, test.mode) != test.exp {
+			t.Errorf(
+				"%q: held %s, mode %d: got: %t, exp: %t",
+				test.spec,
+				test.held,
+				test.mode,
+				!test.exp,
+				test.exp,
+			)
+		}
+	}
+}
+
+func TestRegistry(t *testing.T) {
+	var (
+		reg    *hotkey.Registry
+		now    time.Time
+		fired  []string
+		record func(name string) hotkey.Callback
+		press  func(key input.KeyCode, value int32)
+		err    error
+	)
+
+	t.Parallel()
+
+	reg = hotkey.NewRegistry()
+	now = time.Unix(0, 0)
+
+	record = func(name string) hotkey.Callback {
+		return func(input.Event) {
+			fired = append(fired, name)
+		}
+	}
+
+	err = reg.Register("KEY_LEFTMETA+KEY_ENTER", record("combo"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = reg.Register("ctrl+KEY_SPACE KEY_T", record("sequence"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = reg.RegisterHold("KEY_ESC", time.Second, record("hold"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	press = func(key input.KeyCode, value int32) {
+		reg.Feed(0, input.Event{Type: input.EV_KEY, Code: uint16(key), Value: value}, now)
+	}
+
+	press(input.KEY_LEFTMETA, 1)
+	press(input.KEY_ENTER, 1)
+	press(input.KEY_ENTER, 0)
+	press(input.KEY_LEFTSHIFT, 1)
+	press(input.KEY_ENTER, 1)
+	press(input.KEY_ENTER, 0)
+	press(input.KEY_LEFTSHIFT, 0)
+	press(input.KEY_LEFTMETA, 0)
+
+	press(input.KEY_RIGHTCTRL, 1)
+	press(input.KEY_SPACE, 1)
+	press(input.KEY_SPACE, 0)
+	press(input.KEY_RIGHTCTRL, 0)
+	now = now.Add(500 * time.Millisecond)
+	press(input.KEY_T, 1)
+	press(input.KEY_T, 0)
+
+	press(input.KEY_LEFTCTRL, 1)
+	press(input.KEY_SPACE, 1)
+	press(input.KEY_LEFTCTRL, 0)
+	now = now.Add(2 * time.Second)
+	reg.Tick(now)
+	press(input.KEY_T, 1)
+	press(input.KEY_T, 0)
+
+	press(input.KEY_ESC, 1)
+	now = now.Add(500 * time.Millisecond)
+	reg.Tick(now)
+	press(input.KEY_ESC, 0)
+	press(input.KEY_ESC, 1)
+	now = now.Add(time.Second)
+	reg.Tick(now)
+	press(input.KEY_ESC, 0)
+
+	if len(fired) != 3 || fired[0] != "combo" || fired[1] != "sequence" || fired[2] != "hold" {
+		t.Errorf("got: %v, exp: [combo sequence hold]", fired)
+	}
+}
+
+func TestRegistryDropped(t *testing.T) {
+	var (
+		reg *hotkey.Registry
+		now time.Time
+	)
+
+	t.Parallel()
+
+	reg = hotkey.NewRegistry()
+	now = time.Unix(0, 0)
+
+	reg.Feed(0, input.Event{Type: input.EV_KEY, Code: uint16(input.KEY_LEFTMETA), Value: 1}, now)
+	reg.Feed(1, input.Event{Type: input.EV_KEY, Code: uint16(input.KEY_LEFTCTRL), Value: 1}, now)
+	reg.Feed(1, input.Event{Type: input.EV_SYN, Code: uint16(input.SYN_DROPPED)}, now)
+
+	if reg.Held() != hotkey.ModLeftMeta {
+		t.Errorf("got: %s, exp: %s", reg.Held(), hotkey.ModLeftMeta)
+	}
+}
@@ -0,0 +1,134 @@
+// Package inputerr classifies the errors returned by the evdev and uinput
+// packages. Failed device operations are reported as an [*OpError]
+// recording the operation and device path, and an [*OpError] whose errno
+// is classified, as told by [Kind], matches one of the sentinel errors
+// below with [errors.Is], so callers can tell a missing permission from
+// an unplugged device without inspecting errno values. Other errnos, such
+// as EIO, match none of them:
+//
+//	dev, err = evdev.NewDevice(path)
+//	if errors.Is(err, inputerr.ErrPermission) {
+//		// Suggest joining the input group.
+//	}
+package inputerr
+
+import (
+	"errors"
+	"io/fs"
+	"syscall"
+)
+
+var (
+	// ErrPermission means the caller lacks permission to open or operate
+	// on the device, as reported by EACCES and EPERM. Device nodes under
+	// /dev/input are usually readable by the input group.
+	ErrPermission error = errors.New("permission denied")
+
+	// ErrNotFound means the device node does not exist, as reported by
+	// ENOENT.
+	ErrNotFound error = errors.New("device not found")
+
+	// ErrNotEvdev means the file is not an input device node, as reported
+	// by ENOTTY when an input ioctl is issued on another kind of file.
+	ErrNotEvdev error = errors.New("not an input device")
+
+	// ErrDeviceGone means the device was removed while open, as reported
+	// by ENODEV and ENXIO.
+	ErrDeviceGone error = errors.New("device is gone")
+
+	// ErrBusy means the device is busy or grabbed by another client, as
+	// reported by EBUSY.
+	ErrBusy error = errors.New("device is busy or grabbed")
+
+	// ErrUnsupported means the device or kernel does not support the
+	// requested operation, as reported by EINVAL, EOPNOTSUPP and ENOSYS.
+	// Input devices answer unknown ioctls with EINVAL.
+	ErrUnsupported error = errors.New("operation not supported")
+)
+
+// OpError records a failed device operation.
+type OpError struct {
+	// Op describes the operation, such as "failed to grab evdev device".
+	Op string
+
+	// Path is the device file the operation was performed on.
+	Path string
+
+	// Err is the underlying error.
+	Err error
+}
+
+// Wrap returns an [*OpError] for err, or nil if err is nil. The path
+// recorded by an [*fs.PathError] is dropped in favour of path, so the
+// device is not named twice.
+func Wrap(op, path string, err error) error {
+	var pathErr *fs.PathError
+
+	if err == nil {
+		return nil
+	}
+
+	if errors.As(err, &pathErr) {
+		err = pathErr.Err
+	}
+
+	return &OpError{
+		Op:   op,
+		Path: path,
+		Err:  err,
+	}
+}
+
+// Kind returns the sentinel error that classifies err, or nil if the
+// errno in err's chain, if any, is not classified.
+func Kind(err error) error {
+	var errno syscall.Errno
+
+	if !errors.As(err, &errno) {
+		return nil
+	}
+
+	switch errno {
+	case syscall.EACCES, syscall.EPERM:
+		return ErrPermission
+	case syscall.ENOENT:
+		return ErrNotFound
+	case syscall.ENOTTY:
+		return ErrNotEvdev
+	case syscall.ENODEV, syscall.ENXIO:
+		return ErrDeviceGone
+	case syscall.EBUSY:
+		return ErrBusy
+	case syscall.EINVAL, syscall.EOPNOTSUPP, syscall.ENOSYS:
+		return ErrUnsupported
+	default:
+		return nil
+	}
+}
+
+// Error formats the error as "path: op: err", omitting empty parts.
+func (opErr *OpError) Error() string {
+	var str string
+
+	str = opErr.Op
+	if opErr.Path != "" {
+		str = opErr.Path + ": " + str
+	}
+
+	if opErr.Err == nil {
+		return str
+	}
+
+	return str + ": " + opErr.Err.Error()
+}
+
+// Unwrap returns the underlying error.
+func (opErr *OpError) Unwrap() error {
+	return opErr.Err
+}
+
+// Is reports whether target is the sentinel error classifying the
+// underlying error.
+func (opErr *OpError) Is(target error) bool {
+	return target != nil && target == Kind(opErr.Err)
+}
@@ -0,0 +1,89 @@
+package inputerr_test
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"syscall"
+	"testing"
+
+	"github.com/andrieee44/gopkg/linux/inputerr"
+)
+
+func TestKind(t *testing.T) {
+	type table struct {
+		errno syscall.Errno
+		exp   error
+	}
+
+	var (
+		tests []table
+		test  table
+		err   error
+	)
+
+	t.Parallel()
+
+	tests = []table{
+		{syscall.EACCES, inputerr.ErrPermission},
+		{syscall.EPERM, inputerr.ErrPermission},
+		{syscall.ENOENT, inputerr.ErrNotFound},
+		{syscall.ENOTTY, inputerr.ErrNotEvdev},
+		{syscall.ENODEV, inputerr.ErrDeviceGone},
+		{syscall.EBUSY, inputerr.ErrBusy},
+		{syscall.EINVAL, inputerr.ErrUnsupported},
+		{syscall.EIO, nil},
+	}
+
+	for _, test = range tests {
+		err = fmt.Errorf("outer: %w", inputerr.Wrap("failed to open", "/dev/input/event0", test.errno))
+
+		if inputerr.Kind(err) != test.exp {
+			t.Errorf("%v: got: %v, exp: %v", test.errno, inputerr.Kind(err), test.exp)
+		}
+
+		if test.exp != nil && !errors.Is(err, test.exp) {
+			t.Errorf("%v: errors.Is: got: false, exp: true", test.errno)
+		}
+
+		if !errors.Is(err, test.errno) {
+			t.Errorf("%v: errors.Is errno: got: false, exp: true", test.errno)
+		}
+	}
+}
+
+func TestWrap(t *testing.T) {
+	var (
+		err     error
+		opErr   *inputerr.OpError
+		exp     string
+		pathErr error
+	)
+
+	t.Parallel()
+
+	if inputerr.Wrap("op", "path", nil) != nil {
+		t.Errorf("nil: got: non-nil, exp: nil")
+	}
+
+	pathErr = &fs.PathError{Op: "open", Path: "/dev/input/event9", Err: syscall.ENOENT}
+	err = inputerr.Wrap("failed to open evdev device", "/dev/input/event9", pathErr)
+
+	exp = "/dev/input/event9: failed to open evdev device: no such file or directory"
+	if err.Error() != exp {
+		t.Errorf("got: %s, exp: %s", err, exp)
+	}
+
+	if !errors.As(err, &opErr) || opErr.Path != "/dev/input/event9" {
+		t.Errorf("errors.As: got: %v", opErr)
+	}
+
+	if !errors.Is(err, inputerr.ErrNotFound) || !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("errors.Is: got: false, exp: true")
+	}
+
+	if errors.Is(err, inputerr.ErrPermission) {
+		t.Errorf("errors.Is permission: got: true, exp: false")
+	}
+}
@@ -0,0 +1,100 @@
+// Package evdevmux reads the events of several evdev devices into a
+// single channel, for the packages that watch or merge many devices.
+package evdevmux
+
+import (
+	"context"
+	"fmt"
+	"io"
+
+	"github.com/andrieee44/gopkg/linux/evdev"
+	"github.com/andrieee44/gopkg/linux/uapi/input"
+)
+
+// Event is an event read from the device with index Source.
+type Event struct {
+	// Source is the index of the device the event was read from.
+	Source int
+
+	// Event is the event read.
+	Event input.Event
+}
+
+// Read starts [evdev.Device.ReadEvents] on every device and returns a
+// channel of their events and a channel of their errors, each wrapped
+// with the device's file name and errMsg. A device reaching end of file
+// is reported as [io.EOF]. Each device reports at most one error, and
+// the error channel is buffered for all of them. Reading stops when ctx
+// is cancelled, so cancel it once done with the events.
+func Read(
+	ctx context.Context,
+	devices []*evdev.Device,
+	errMsg string,
+) (<-chan Event, <-chan error) {
+	var (
+		events chan Event
+		errs   chan error
+		idx    int
+	)
+
+	events = make(chan Event)
+	errs = make(chan error, len(devices))
+
+	for idx = range devices {
+		go forward(ctx, idx, devices[idx], events, errs, errMsg)
+	}
+
+	return events, errs
+}
+
+// forward passes the events and errors of device, the device with index
+// source, on to events and errs until ctx is cancelled.
+func forward(
+	ctx context.Context,
+	source int,
+	device *evdev.Device,
+	events chan<- Event,
+	errs chan<- error,
+	errMsg string,
+) {
+	var (
+		eventsChan <-chan input.Event
+		errChan    <-chan error
+		event      input.Event
+		ok         bool
+		err        error
+	)
+
+	eventsChan, errChan = device.ReadEvents()
+
+	for eventsChan != nil || errChan != nil {
+		select {
+		case <-ctx.Done():
+			return
+		case event, ok = <-eventsChan:
+			if !ok {
+				eventsChan = nil
+
+				continue
+			}
+
+			select {
+			case events <- Event{Source: source, Event: event}:
+			case <-ctx.Done():
+				return
+			}
+		case err, ok = <-errChan:
+			if !ok {
+				errChan = nil
+
+				continue
+			}
+
+			errs <- fmt.Errorf("%s: %s: %w", device.Filename(), errMsg, err)
+
+			return
+		}
+	}
+
+	errs <- fmt.Errorf("%s: %s: %w", device.Filename(), errMsg, io.EOF)
+}
@@ -4,14 +4,14 @@
 package inputwrap
 
 import (
-	"fmt"
 	"os"
 
+	"github.com/andrieee44/gopkg/linux/inputerr"
 	"github.com/andrieee44/gopkg/linux/uapi/input"
 )
 
 // GetBitmask wraps [input.GetBitmask] and wraps the returned error with
-// the file name and a custom message.
+// the file name and a custom message as an [inputerr.OpError].
 func GetBitmask[T input.Code](
 	file *os.File,
 	req func(length uint32) (uint32, error),
@@ -25,7 +25,7 @@ func GetBitmask[T input.Code](
 
 	codes, err = input.GetBitmask(file.Fd(), req, count)
 	if err != nil {
-		return nil, fmt.Errorf("%s: %s: %w", file.Name(), errMsg, err)
+		return nil, inputerr.Wrap(errMsg, file.Name(), err)
 	}
 
 	return codes, nil
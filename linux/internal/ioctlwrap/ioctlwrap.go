@@ -7,6 +7,7 @@ import (
 	"fmt"
 	"os"
 
+	"github.com/andrieee44/gopkg/linux/inputerr"
 	"github.com/andrieee44/gopkg/linux/uapi/ioctl"
 )
 
@@ -96,7 +97,7 @@ func IOC(dir, typ, nr, size uint32, errMsg string) (uint32, error) {
 }
 
 // GetAny wraps [ioctl.GetAny] and wraps the returned error with the file
-// name and a custom message.
+// name and a custom message as an [inputerr.OpError].
 func GetAny[T any](
 	file *os.File,
 	reqFn func() (uint32, error),
@@ -110,7 +111,7 @@ func GetAny[T any](
 
 	result, err = ioctl.GetAny(file.Fd(), reqFn, arg)
 	if err != nil {
-		return *new(T), fmt.Errorf("%s: %s: %w", file.Name(), errMsg, err)
+		return *new(T), inputerr.Wrap(errMsg, file.Name(), err)
 	}
 
 	return result, nil
@@ -135,7 +136,7 @@ func SetAny[T any](
 }
 
 // GetStr wraps [ioctl.GetStr] and wraps the returned error with the file
-// name and a custom message.
+// name and a custom message as an [inputerr.OpError].
 func GetStr(
 	file *os.File,
 	reqFn func(length uint32) (uint32, error),
@@ -149,20 +150,20 @@ func GetStr(
 
 	str, err = ioctl.GetStr(file.Fd(), reqFn, bufSize)
 	if err != nil {
-		return "", fmt.Errorf("%s: %s: %w", file.Name(), errMsg, err)
+		return "", inputerr.Wrap(errMsg, file.Name(), err)
 	}
 
 	return str, nil
 }
 
 // Empty wraps [ioctl.Empty] and prewraps any returned error with the file
-// name and a custom message.
+// name and a custom message as an [inputerr.OpError].
 func Empty(file *os.File, reqFn func() (uint32, error), errMsg string) error {
 	var err error
 
 	err = ioctl.Empty(file.Fd(), reqFn)
 	if err != nil {
-		return fmt.Errorf("%s: %s: %w", file.Name(), errMsg, err)
+		return inputerr.Wrap(errMsg, file.Name(), err)
 	}
 
 	return nil
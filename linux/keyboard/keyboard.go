@@ -0,0 +1,290 @@
+// Package keyboard types text through a virtual keyboard. A [Keyboard]
+// turns each character of a string into the key strokes that produce it
+// under a [Layout], holding Shift and AltGr as needed, and sends them to
+// a [uinput.Emitter] such as a [uinput.Device]. Characters the layout
+// cannot type go through a [Fallback], such as [UnicodeInput], which
+// enters them by code point with Ctrl+Shift+U.
+//
+// It is intended for automation and password manager autotype, which on
+// Wayland cannot inject text into other clients directly.
+package keyboard
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"slices"
+	"strconv"
+	"time"
+
+	"github.com/andrieee44/gopkg/linux/uapi/input"
+	"github.com/andrieee44/gopkg/linux/uinput"
+)
+
+// ErrUnmappable is returned when a character is not in the layout and no
+// [Fallback] is set.
+var ErrUnmappable error = errors.New("character cannot be typed with the layout")
+
+// Fallback types a character missing from the keyboard's layout.
+type Fallback func(ctx context.Context, kbd *Keyboard, char rune) error
+
+// Options configures a [Keyboard].
+type Options struct {
+	// Layout is the layout active on the receiving side. It defaults to
+	// [US].
+	Layout Layout
+
+	// Delay is the pause after each typed character. Applications and
+	// compositors may drop or reorder keys sent too fast.
+	Delay time.Duration
+
+	// Jitter adds a random pause of up to Jitter to each Delay, making
+	// typing look less mechanical.
+	Jitter time.Duration
+
+	// Fallback types characters missing from Layout. If nil, such
+	// characters fail with [ErrUnmappable].
+	Fallback Fallback
+}
+
+// Keyboard types text through a [uinput.Emitter].
+type Keyboard struct {
+	emitter uinput.Emitter
+	opts    Options
+}
+
+// New returns a [Keyboard] sending events to emitter, which must support
+// the keys returned by [Keys] for opts.Layout.
+func New(emitter uinput.Emitter, opts Options) *Keyboard {
+	if opts.Layout == nil {
+		opts.Layout = US
+	}
+
+	return &Keyboard{
+		emitter: emitter,
+		opts:    opts,
+	}
+}
+
+// Keys returns the keys a [Keyboard] with layout may press: those of
+// the layout, both modifiers and the keys used by [UnicodeInput].
+func Keys(layout Layout) []input.KeyCode {
+	var keys []input.KeyCode
+
+	keys = append(
+		layout.Keys(),
+		input.KEY_LEFTCTRL,
+		input.KEY_LEFTSHIFT,
+		input.KEY_RIGHTALT,
+		input.KEY_SPACE,
+	)
+
+	slices.Sort(keys)
+
+	return slices.Compact(keys)
+}
+
+// NewDevice creates a virtual keyboard called name through uinput that
+// supports the [Keys] of layout. Pass it to [New], and close it with
+// [uinput.Device.Close] when done.
+func NewDevice(layout Layout, name string) (*uinput.Device, error) {
+	var (
+		dev *uinput.Device
+		err error
+	)
+
+	dev, err = uinput.NewDevice(input.ID{Bustype: input.BUS_VIRTUAL}, name)
+	if err != nil {
+		return nil, err
+	}
+
+	err = dev.SetEvents([]input.EventCode{input.EV_KEY})
+	if err == nil {
+		err = dev.SetKeys(Keys(layout))
+	}
+
+	if err == nil {
+		err = dev.Create()
+	}
+
+	if err != nil {
+		return nil, errors.Join(err, dev.Close())
+	}
+
+	return dev, nil
+}
+
+// Layout returns the keyboard's layout.
+func (kbd *Keyboard) Layout() Layout {
+	return kbd.opts.Layout
+}
+
+// Type types text one character at a time, pausing after each as set by
+// [Options]. It stops at the first character that fails or when ctx is
+// done; the characters before it have been typed.
+func (kbd *Keyboard) Type(ctx context.Context, text string) error {
+	var (
+		char rune
+		err  error
+	)
+
+	for _, char = range text {
+		err = kbd.TypeRune(ctx, char)
+		if err != nil {
+			return err
+		}
+
+		err = kbd.Pause(ctx)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// TypeRune types a single character, using the fallback if the layout
+// has no stroke for it.
+func (kbd *Keyboard) TypeRune(ctx context.Context, char rune) error {
+	var (
+		stroke Stroke
+		ok     bool
+	)
+
+	stroke, ok = kbd.opts.Layout[char]
+	if ok {
+		return kbd.Stroke(stroke)
+	}
+
+	if kbd.opts.Fallback == nil {
+		return fmt.Errorf("failed to type %q: %w", char, ErrUnmappable)
+	}
+
+	return kbd.opts.Fallback(ctx, kbd, char)
+}
+
+// Stroke presses and releases stroke's key, holding its modifiers around
+// it. Modifiers go in their own frames so that they are seen before the
+// key.
+func (kbd *Keyboard) Stroke(stroke Stroke) error {
+	var mods []input.KeyCode
+
+	if stroke.Shift {
+		mods = append(mods, input.KEY_LEFTSHIFT)
+	}
+
+	if stroke.AltGr {
+		mods = append(mods, input.KEY_RIGHTALT)
+	}
+
+	return kbd.Chord(mods, stroke.Key)
+}
+
+// Chord presses mods in order, taps key and releases mods in reverse
+// order.
+func (kbd *Keyboard) Chord(mods []input.KeyCode, key input.KeyCode) error {
+	var (
+		events []input.Event
+		mod    input.KeyCode
+	)
+
+	for _, mod = range mods {
+		events = append(events, uinput.KeyEvent(mod, 1))
+	}
+
+	if len(mods) != 0 {
+		events = append(events, uinput.SyncEvent())
+	}
+
+	events = append(
+		events,
+		uinput.KeyEvent(key, 1),
+		uinput.SyncEvent(),
+		uinput.KeyEvent(key, 0),
+		uinput.SyncEvent(),
+	)
+
+	for _, mod = range slices.Backward(mods) {
+		events = append(events, uinput.KeyEvent(mod, 0))
+	}
+
+	if len(mods) != 0 {
+		events = append(events, uinput.SyncEvent())
+	}
+
+	return kbd.emitter.EmitBatch(events)
+}
+
+// Pause waits for the configured delay and jitter, returning early with
+// ctx's error when ctx is done.
+func (kbd *Keyboard) Pause(ctx context.Context) error {
+	var (
+		pause time.Duration
+		timer *time.Timer
+	)
+
+	pause = kbd.opts.Delay
+	if kbd.opts.Jitter > 0 {
+		pause += rand.N(kbd.opts.Jitter + 1)
+	}
+
+	if pause <= 0 {
+		return ctx.Err()
+	}
+
+	timer = time.NewTimer(pause)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+// UnicodeInput is a [Fallback] that enters a character by its code point
+// with the Ctrl+Shift+U method understood by GTK, IBus and many other
+// input methods: Ctrl+Shift+U, the code point in hexadecimal, then Space.
+// The hex digits and U are typed through the keyboard's layout.
+func UnicodeInput(ctx context.Context, kbd *Keyboard, char rune) error {
+	var (
+		digits string
+		digit  rune
+		stroke Stroke
+		ok     bool
+		err    error
+	)
+
+	stroke, ok = kbd.opts.Layout['u']
+	if !ok {
+		return fmt.Errorf("failed to type %q: u: %w", char, ErrUnmappable)
+	}
+
+	err = kbd.Chord([]input.KeyCode{input.KEY_LEFTCTRL, input.KEY_LEFTSHIFT}, stroke.Key)
+	if err != nil {
+		return err
+	}
+
+	digits = strconv.FormatInt(int64(char), 16)
+
+	for _, digit = range digits + " " {
+		err = kbd.Pause(ctx)
+		if err != nil {
+			return err
+		}
+
+		stroke, ok = kbd.opts.Layout[digit]
+		if !ok {
+			return fmt.Errorf("failed to type %q: %q: %w", char, digit, ErrUnmappable)
+		}
+
+		err = kbd.Stroke(stroke)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,172 @@
+package keyboard_test
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+
+	"github.com/andrieee44/gopkg/linux/keyboard"
+	"github.com/andrieee44/gopkg/linux/uapi/input"
+	"github.com/andrieee44/gopkg/linux/uinput/uinputtest"
+)
+
+// pressed returns the keys pressed, in order, with the modifiers held at
+// the time of each press.
+func pressed(events []input.Event) []string {
+	var (
+		held  []input.KeyCode
+		keys  []string
+		event input.Event
+		key   input.KeyCode
+		name  string
+		mod   input.KeyCode
+	)
+
+	for _, event = range events {
+		if event.Type != input.EV_KEY {
+			continue
+		}
+
+		key = input.KeyCode(event.Code)
+
+		switch {
+		case event.Value == 0:
+			held = slices.DeleteFunc(held, func(other input.KeyCode) bool { return other == key })
+		case isModifier(key):
+			held = append(held, key)
+		default:
+			name = ""
+			for _, mod = range held {
+				name += mod.String() + "+"
+			}
+
+			keys = append(keys, name+key.String())
+		}
+	}
+
+	return keys
+}
+
+func isModifier(key input.KeyCode) bool {
+	return key == input.KEY_LEFTSHIFT || key == input.KEY_RIGHTALT || key == input.KEY_LEFTCTRL
+}
+
+func TestType(t *testing.T) {
+	type table struct {
+		layout keyboard.Layout
+		text   string
+		exp    []string
+	}
+
+	var (
+		tests []table
+		test  table
+		rec   *uinputtest.Recorder
+		kbd   *keyboard.Keyboard
+		err   error
+	)
+
+	t.Parallel()
+
+	tests = []table{
+		{keyboard.US, "aB\n", []string{"KEY_A", "KEY_LEFTSHIFT+KEY_B", "KEY_ENTER"}},
+		{keyboard.US, "@", []string{"KEY_LEFTSHIFT+KEY_2"}},
+		{keyboard.DE, "zy", []string{"KEY_Y", "KEY_Z"}},
+		{keyboard.DE, "@{", []string{"KEY_RIGHTALT+KEY_Q", "KEY_RIGHTALT+KEY_7"}},
+		{keyboard.DE, "Ä_", []string{"KEY_LEFTSHIFT+KEY_APOSTROPHE", "KEY_LEFTSHIFT+KEY_SLASH"}},
+	}
+
+	for _, test = range tests {
+		rec = new(uinputtest.Recorder)
+		kbd = keyboard.New(rec, keyboard.Options{Layout: test.layout})
+
+		err = kbd.Type(context.Background(), test.text)
+		if err != nil {
+			t.Errorf("%q: got: %v, exp: nil", test.text, err)
+
+			continue
+		}
+
+		if !slices.Equal(pressed(rec.Events()), test.exp) {
+			t.Errorf("%q: got: %v, exp: %v", test.text, pressed(rec.Events()), test.exp)
+		}
+	}
+}
+
+func TestUnicodeInput(t *testing.T) {
+	var (
+		rec *uinputtest.Recorder
+		kbd *keyboard.Keyboard
+		exp []string
+		err error
+	)
+
+	t.Parallel()
+
+	rec = new(uinputtest.Recorder)
+	kbd = keyboard.New(rec, keyboard.Options{Fallback: keyboard.UnicodeInput})
+
+	err = kbd.Type(context.Background(), "é")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	exp = []string{"KEY_LEFTCTRL+KEY_LEFTSHIFT+KEY_U", "KEY_E", "KEY_9", "KEY_SPACE"}
+	if !slices.Equal(pressed(rec.Events()), exp) {
+		t.Errorf("got: %v, exp: %v", pressed(rec.Events()), exp)
+	}
+
+	err = keyboard.New(rec, keyboard.Options{}).Type(context.Background(), "é")
+	if !errors.Is(err, keyboard.ErrUnmappable) {
+		t.Errorf("no fallback: got: %v, exp: %v", err, keyboard.ErrUnmappable)
+	}
+}
+
+func TestTypeCanceled(t *testing.T) {
+	var (
+		rec    *uinputtest.Recorder
+		kbd    *keyboard.Keyboard
+		ctx    context.Context
+		cancel context.CancelFunc
+		err    error
+	)
+
+	t.Parallel()
+
+	rec = new(uinputtest.Recorder)
+	kbd = keyboard.New(rec, keyboard.Options{Delay: 1 << 62})
+	ctx, cancel = context.WithCancel(context.Background())
+	cancel()
+
+	err = kbd.Type(ctx, "abc")
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("got: %v, exp: %v", err, context.Canceled)
+	}
+
+	if len(pressed(rec.Events())) != 1 {
+		t.Errorf("pressed: got: %v, exp: [KEY_A]", pressed(rec.Events()))
+	}
+}
+
+func TestKeys(t *testing.T) {
+	var (
+		keys, exp []input.KeyCode
+		key       input.KeyCode
+	)
+
+	t.Parallel()
+
+	keys = keyboard.Keys(keyboard.DE)
+	exp = []input.KeyCode{input.KEY_102ND, input.KEY_RIGHTALT, input.KEY_LEFTCTRL, input.KEY_Z}
+
+	for _, key = range exp {
+		if !slices.Contains(keys, key) {
+			t.Errorf("%s: got: missing, exp: present", key)
+		}
+	}
+
+	if !slices.IsSorted(keys) || len(slices.Compact(slices.Clone(keys))) != len(keys) {
+		t.Errorf("got: %v, exp: sorted and unique", keys)
+	}
+}
@@ -0,0 +1,168 @@
+package keyboard
+
+import (
+	"maps"
+	"slices"
+
+	"github.com/andrieee44/gopkg/linux/uapi/input"
+)
+
+// Stroke is a key press producing one character: a key, possibly
+// together with Shift and AltGr.
+type Stroke struct {
+	// Key is the key pressed.
+	Key input.KeyCode
+
+	// Shift holds [input.KEY_LEFTSHIFT] during the press.
+	Shift bool
+
+	// AltGr holds [input.KEY_RIGHTALT] during the press.
+	AltGr bool
+}
+
+// Layout maps characters to the strokes that type them under a keyboard
+// layout configured in the compositor or console. The virtual keyboard
+// sends key codes; which characters they produce is decided by the layout
+// active on the receiving side, so the [Layout] must match it.
+type Layout map[rune]Stroke
+
+// level lists the characters of one key at its shift levels. A zero rune
+// means the level types nothing useful, such as a dead key.
+type level struct {
+	key                 input.KeyCode
+	plain, shift, altGr rune
+}
+
+// US is the US QWERTY layout.
+var US Layout = newLayout([]level{
+	{input.KEY_GRAVE, '`', '~', 0},
+	{input.KEY_1, '1', '!', 0},
+	{input.KEY_2, '2', '@', 0},
+	{input.KEY_3, '3', '#', 0},
+	{input.KEY_4, '4', '$', 0},
+	{input.KEY_5, '5', '%', 0},
+	{input.KEY_6, '6', '^', 0},
+	{input.KEY_7, '7', '&', 0},
+	{input.KEY_8, '8', '*', 0},
+	{input.KEY_9, '9', '(', 0},
+	{input.KEY_0, '0', ')', 0},
+	{input.KEY_MINUS, '-', '_', 0},
+	{input.KEY_EQUAL, '=', '+', 0},
+	{input.KEY_LEFTBRACE, '[', '{', 0},
+	{input.KEY_RIGHTBRACE, ']', '}', 0},
+	{input.KEY_BACKSLASH, '\\', '|', 0},
+	{input.KEY_SEMICOLON, ';', ':', 0},
+	{input.KEY_APOSTROPHE, '\'', '"', 0},
+	{input.KEY_COMMA, ',', '<', 0},
+	{input.KEY_DOT, '.', '>', 0},
+	{input.KEY_SLASH, '/', '?', 0},
+})
+
+// DE is the German QWERTZ layout. Dead keys, such as ^ and ´, are left
+// out, as typing them needs a second key press.
+var DE Layout = newLayout([]level{
+	{input.KEY_GRAVE, 0, '°', 0},
+	{input.KEY_1, '1', '!', 0},
+	{input.KEY_2, '2', '"', '²'},
+	{input.KEY_3, '3', '§', '³'},
+	{input.KEY_4, '4', '$', 0},
+	{input.KEY_5, '5', '%', 0},
+	{input.KEY_6, '6', '&', 0},
+	{input.KEY_7, '7', '/', '{'},
+	{input.KEY_8, '8', '(', '['},
+	{input.KEY_9, '9', ')', ']'},
+	{input.KEY_0, '0', '=', '}'},
+	{input.KEY_MINUS, 'ß', '?', '\\'},
+	{input.KEY_Q, 'q', 'Q', '@'},
+	{input.KEY_E, 'e', 'E', '€'},
+	{input.KEY_M, 'm', 'M', 'µ'},
+	{input.KEY_LEFTBRACE, 'ü', 'Ü', 0},
+	{input.KEY_RIGHTBRACE, '+', '*', 0},
+	{input.KEY_SEMICOLON, 'ö', 'Ö', 0},
+	{input.KEY_APOSTROPHE, 'ä', 'Ä', 0},
+	{input.KEY_BACKSLASH, '#', '\'', 0},
+	{input.KEY_102ND, '<', '>', '|'},
+	{input.KEY_COMMA, ',', ';', 0},
+	{input.KEY_DOT, '.', ':', 0},
+	{input.KEY_SLASH, '-', '_', 0},
+	{input.KEY_Y, 'z', 'Z', 0},
+	{input.KEY_Z, 'y', 'Y', 0},
+})
+
+// Keys returns every key the layout presses, including the Shift and
+// AltGr modifiers it uses, in ascending order.
+func (layout Layout) Keys() []input.KeyCode {
+	var (
+		keys   map[input.KeyCode]bool
+		stroke Stroke
+	)
+
+	keys = make(map[input.KeyCode]bool)
+
+	for _, stroke = range layout {
+		keys[stroke.Key] = true
+
+		if stroke.Shift {
+			keys[input.KEY_LEFTSHIFT] = true
+		}
+
+		if stroke.AltGr {
+			keys[input.KEY_RIGHTALT] = true
+		}
+	}
+
+	return slices.Sorted(maps.Keys(keys))
+}
+
+// newLayout builds a layout from the keys that differ between layouts on
+// top of the letters and whitespace keys shared by all Latin layouts.
+// Later entries replace earlier ones.
+func newLayout(levels []level) Layout {
+	var (
+		layout Layout
+		letter rune
+		entry  level
+	)
+
+	layout = Layout{
+		' ':  {Key: input.KEY_SPACE},
+		'\t': {Key: input.KEY_TAB},
+		'\n': {Key: input.KEY_ENTER},
+	}
+
+	for letter = 'a'; letter <= 'z'; letter++ {
+		layout.add(level{letterKeys[letter-'a'], letter, letter - 'a' + 'A', 0})
+	}
+
+	for _, entry = range levels {
+		layout.add(entry)
+	}
+
+	return layout
+}
+
+// add sets the characters of entry, replacing strokes that typed them on
+// other keys.
+func (layout Layout) add(entry level) {
+	if entry.plain != 0 {
+		layout[entry.plain] = Stroke{Key: entry.key}
+	}
+
+	if entry.shift != 0 {
+		layout[entry.shift] = Stroke{Key: entry.key, Shift: true}
+	}
+
+	if entry.altGr != 0 {
+		layout[entry.altGr] = Stroke{Key: entry.key, AltGr: true}
+	}
+}
+
+// letterKeys lists the keys of a to z in US positions.
+var letterKeys = [26]input.KeyCode{
+	input.KEY_A, input.KEY_B, input.KEY_C, input.KEY_D, input.KEY_E,
+	input.KEY_F, input.KEY_G, input.KEY_H, input.KEY_I, input.KEY_J,
+	input.KEY_K, input.KEY_L, input.KEY_M, input.KEY_N, input.KEY_O,
+	input.KEY_P, input.KEY_Q, input.KEY_R, input.KEY_S, input.KEY_T,
+	input.KEY_U, input.KEY_V, input.KEY_W, input.KEY_X, input.KEY_Y,
+	input.KEY_Z,
+}
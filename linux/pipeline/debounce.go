@@ -0,0 +1,323 @@
+package pipeline
+
+import (
+	"maps"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/andrieee44/gopkg/linux/uapi/input"
+)
+
+// DefaultDebounceThreshold is the threshold used by [NewDebouncer] when
+// [DebounceOptions].Threshold is zero. Key chatter on worn switches
+// usually settles well within it, while deliberate presses of the same
+// key are further apart.
+const DefaultDebounceThreshold time.Duration = 25 * time.Millisecond
+
+// DebounceMode selects how a [Debouncer] tells presses from bounces.
+type DebounceMode uint8
+
+const (
+	// DebounceEager passes the first change of a key at once and
+	// suppresses further changes until the threshold has passed since
+	// then. It adds no latency. If the key ends up in a different state
+	// than was passed on, that state follows once the threshold has
+	// passed.
+	DebounceEager DebounceMode = iota
+
+	// DebounceDeferred holds every change of a key back until the key
+	// has not changed for the threshold, and drops changes undone
+	// within it. It also filters spurious single presses, at the cost of
+	// delaying every change by the threshold.
+	DebounceDeferred
+)
+
+// DebounceOptions configures a [Debouncer].
+type DebounceOptions struct {
+	// Mode selects eager or deferred debouncing.
+	Mode DebounceMode
+
+	// Threshold is the debounce time of every key not in Keys. It
+	// defaults to [DefaultDebounceThreshold].
+	Threshold time.Duration
+
+	// Keys overrides Threshold for individual keys. A zero threshold
+	// turns debouncing off for the key.
+	Keys map[input.KeyCode]time.Duration
+}
+
+// DebounceStats counts the key changes a [Debouncer] suppressed as
+// bounces.
+type DebounceStats struct {
+	// Suppressed is the number of key presses and releases dropped.
+	// Changes held back and passed on later are not counted.
+	Suppressed uint64
+
+	// Keys holds the number of suppressed presses and releases of each
+	// key that bounced.
+	Keys map[input.KeyCode]uint64
+}
+
+// Debouncer is a [Deferrer] suppressing the chatter of worn key switches,
+// which report a single press or release as several in quick succession.
+// It works on the [input.EV_KEY] events of frames and times them by their
+// kernel timestamps, so it must see the events of the source as read.
+// Repeats of keys held back or suppressed are dropped; other events pass
+// through. Events due are also flushed by the next frame holding a key
+// event, so a Debouncer is usable without [Deferrer.Flush], at the cost of
+// holding the last change until the next key event. It is safe for
+// concurrent use.
+type Debouncer struct {
+	mode      DebounceMode
+	threshold time.Duration
+	keys      map[input.KeyCode]time.Duration
+	states    map[input.KeyCode]*debounceState
+	stats     DebounceStats
+	mu        sync.Mutex
+}
+
+// debounceState is the state of a key seen by a [Debouncer].
+type debounceState struct {
+	// raw is whether the source last reported the key pressed, and
+	// emitted whether the key was last passed on pressed.
+	raw, emitted bool
+
+	// last is the time of the last change passed on.
+	last time.Time
+
+	// pending is set when raw differs from emitted and the change is
+	// due at due.
+	pending bool
+	due     time.Time
+}
+
+// NewDebouncer returns a [Debouncer] configured by opts.
+func NewDebouncer(opts DebounceOptions) *Debouncer {
+	if opts.Threshold == 0 {
+		opts.Threshold = DefaultDebounceThreshold
+	}
+
+	return &Debouncer{
+		mode:      opts.Mode,
+		threshold: opts.Threshold,
+		keys:      opts.Keys,
+		states:    make(map[input.KeyCode]*debounceState),
+		stats:     DebounceStats{Keys: make(map[input.KeyCode]uint64)},
+	}
+}
+
+// Filter passes on the key changes of frame that are not bounces, along
+// with the held back changes due by the time of its key events.
+func (deb *Debouncer) Filter(frame []input.Event) []input.Event {
+	var (
+		out   []input.Event
+		event input.Event
+		state *debounceState
+		key   input.KeyCode
+		at    time.Time
+	)
+
+	deb.mu.Lock()
+	defer deb.mu.Unlock()
+
+	for _, event = range frame {
+		if event.Type != input.EV_KEY {
+			out = append(out, event)
+
+			continue
+		}
+
+		key = input.KeyCode(event.Code)
+		at = event.Time.Time()
+		out = deb.flush(out, at)
+		state = deb.state(key)
+
+		switch {
+		case event.Value != 0 && event.Value != 1:
+			if state.emitted && !state.pending {
+				out = append(out, event)
+			}
+		case (event.Value == 1) == state.raw:
+		case deb.thresholdOf(key) == 0:
+			state.raw, state.emitted, state.last = event.Value == 1, event.Value == 1, at
+			out = append(out, event)
+		case deb.mode == DebounceDeferred:
+			out = deb.deferred(out, key, state, event)
+		default:
+			out = deb.eager(out, key, state, event)
+		}
+	}
+
+	return out
+}
+
+// Pending returns the time the earliest held back change is due.
+func (deb *Debouncer) Pending() (time.Time, bool) {
+	var (
+		state    *debounceState
+		earliest time.Time
+		found    bool
+	)
+
+	deb.mu.Lock()
+	defer deb.mu.Unlock()
+
+	for _, state = range deb.states {
+		if state.pending && (!found || state.due.Before(earliest)) {
+			earliest, found = state.due, true
+		}
+	}
+
+	return earliest, found
+}
+
+// Flush returns a frame with the held back changes due at now, on the
+// clock of the event timestamps.
+func (deb *Debouncer) Flush(now time.Time) []input.Event {
+	var out []input.Event
+
+	deb.mu.Lock()
+	defer deb.mu.Unlock()
+
+	out = deb.flush(nil, now)
+	if len(out) == 0 {
+		return nil
+	}
+
+	return append(out, input.Event{
+		Time: input.NewEventTime(now),
+		Type: input.EV_SYN,
+		Code: uint16(input.SYN_REPORT),
+	})
+}
+
+// Stats returns the number of changes suppressed so far.
+func (deb *Debouncer) Stats() DebounceStats {
+	deb.mu.Lock()
+	defer deb.mu.Unlock()
+
+	return DebounceStats{Suppressed: deb.stats.Suppressed, Keys: maps.Clone(deb.stats.Keys)}
+}
+
+// eager passes the change event of key on if the threshold has passed
+// since the last change passed on, and holds it back otherwise, dropping
+// it together with the held back change it undoes.
+func (deb *Debouncer) eager(
+	out []input.Event,
+	key input.KeyCode,
+	state *debounceState,
+	event input.Event,
+) []input.Event {
+	var at time.Time
+
+	at = event.Time.Time()
+	state.raw = event.Value == 1
+
+	if state.last.IsZero() || at.Sub(state.last) >= deb.thresholdOf(key) {
+		state.emitted, state.last, state.pending = state.raw, at, false
+
+		return append(out, event)
+	}
+
+	if state.pending {
+		deb.suppress(key, 2)
+		state.pending = false
+
+		return out
+	}
+
+	state.pending = true
+	state.due = state.last.Add(deb.thresholdOf(key))
+
+	return out
+}
+
+// deferred holds the change event of key back for the threshold, or drops
+// it together with the change it undoes.
+func (deb *Debouncer) deferred(
+	out []input.Event,
+	key input.KeyCode,
+	state *debounceState,
+	event input.Event,
+) []input.Event {
+	state.raw = event.Value == 1
+
+	if state.pending {
+		deb.suppress(key, 2)
+		state.pending = false
+
+		return out
+	}
+
+	state.pending = true
+	state.due = event.Time.Time().Add(deb.thresholdOf(key))
+
+	return out
+}
+
+// flush appends the held back changes due at now to out, in key order.
+func (deb *Debouncer) flush(out []input.Event, now time.Time) []input.Event {
+	var (
+		key   input.KeyCode
+		state *debounceState
+		value int32
+	)
+
+	for _, key = range slices.Sorted(maps.Keys(deb.states)) {
+		state = deb.states[key]
+		if !state.pending || state.due.After(now) {
+			continue
+		}
+
+		value = 0
+		if state.raw {
+			value = 1
+		}
+
+		out = append(out, input.Event{
+			Time:  input.NewEventTime(state.due),
+			Type:  input.EV_KEY,
+			Code:  uint16(key),
+			Value: value,
+		})
+
+		state.emitted, state.last, state.pending = state.raw, state.due, false
+	}
+
+	return out
+}
+
+func (deb *Debouncer) state(key input.KeyCode) *debounceState {
+	var (
+		state *debounceState
+		ok    bool
+	)
+
+	state, ok = deb.states[key]
+	if !ok {
+		state = new(debounceState)
+		deb.states[key] = state
+	}
+
+	return state
+}
+
+func (deb *Debouncer) suppress(key input.KeyCode, count uint64) {
+	deb.stats.Suppressed += count
+	deb.stats.Keys[key] += count
+}
+
+func (deb *Debouncer) thresholdOf(key input.KeyCode) time.Duration {
+	var (
+		threshold time.Duration
+		ok        bool
+	)
+
+	threshold, ok = deb.keys[key]
+	if ok {
+		return threshold
+	}
+
+	return deb.threshold
+}
@@ -0,0 +1,210 @@
+package pipeline_test
+
+import (
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/andrieee44/gopkg/linux/pipeline"
+	"github.com/andrieee44/gopkg/linux/uapi/input"
+)
+
+type keyAt struct {
+	at    time.Duration
+	key   input.KeyCode
+	value int32
+}
+
+// debounce feeds steps to deb one frame each, flushing it at each step
+// and finally at end, and returns the key changes passed on.
+func debounce(deb *pipeline.Debouncer, steps []keyAt, end time.Duration) []keyAt {
+	var (
+		start time.Time
+		step  keyAt
+		frame []input.Event
+		event input.Event
+		out   []keyAt
+	)
+
+	start = time.Unix(1000, 0)
+
+	for _, step = range steps {
+		event = input.Event{
+			Time:  input.NewEventTime(start.Add(step.at)),
+			Type:  input.EV_KEY,
+			Code:  uint16(step.key),
+			Value: step.value,
+		}
+
+		frame = append(deb.Flush(start.Add(step.at)), deb.Filter([]input.Event{event, report()})...)
+
+		for _, event = range frame {
+			if event.Type == input.EV_KEY {
+				out = append(out, keyAt{
+					at:    event.Time.Time().Sub(start),
+					key:   input.KeyCode(event.Code),
+					value: event.Value,
+				})
+			}
+		}
+	}
+
+	for _, event = range deb.Flush(start.Add(end)) {
+		if event.Type == input.EV_KEY {
+			out = append(out, keyAt{
+				at:    event.Time.Time().Sub(start),
+				key:   input.KeyCode(event.Code),
+				value: event.Value,
+			})
+		}
+	}
+
+	return out
+}
+
+func TestDebouncer(t *testing.T) {
+	type table struct {
+		name       string
+		opts       pipeline.DebounceOptions
+		steps      []keyAt
+		exp        []keyAt
+		suppressed uint64
+	}
+
+	var (
+		ms    time.Duration
+		tests []table
+		test  table
+		deb   *pipeline.Debouncer
+		got   []keyAt
+	)
+
+	t.Parallel()
+
+	ms = time.Millisecond
+
+	tests = []table{
+		{
+			name: "eager chatter",
+			opts: pipeline.DebounceOptions{Threshold: 10 * ms},
+			steps: []keyAt{
+				{0, input.KEY_A, 1},
+				{2 * ms, input.KEY_A, 0},
+				{3 * ms, input.KEY_A, 1},
+				{50 * ms, input.KEY_A, 2},
+				{80 * ms, input.KEY_A, 0},
+			},
+			exp: []keyAt{
+				{0, input.KEY_A, 1},
+				{50 * ms, input.KEY_A, 2},
+				{80 * ms, input.KEY_A, 0},
+			},
+			suppressed: 2,
+		},
+		{
+			name: "eager quick tap",
+			opts: pipeline.DebounceOptions{Threshold: 10 * ms},
+			steps: []keyAt{
+				{0, input.KEY_A, 1},
+				{4 * ms, input.KEY_A, 0},
+			},
+			exp: []keyAt{
+				{0, input.KEY_A, 1},
+				{10 * ms, input.KEY_A, 0},
+			},
+			suppressed: 0,
+		},
+		{
+			name: "deferred",
+			opts: pipeline.DebounceOptions{Mode: pipeline.DebounceDeferred, Threshold: 10 * ms},
+			steps: []keyAt{
+				{0, input.KEY_A, 1},
+				{2 * ms, input.KEY_A, 0},
+				{4 * ms, input.KEY_A, 1},
+				{40 * ms, input.KEY_A, 0},
+				{100 * ms, input.KEY_B, 1},
+				{103 * ms, input.KEY_B, 0},
+			},
+			exp: []keyAt{
+				{14 * ms, input.KEY_A, 1},
+				{50 * ms, input.KEY_A, 0},
+			},
+			suppressed: 4,
+		},
+		{
+			name: "per key",
+			opts: pipeline.DebounceOptions{
+				Keys: map[input.KeyCode]time.Duration{input.KEY_SPACE: 0},
+			},
+			steps: []keyAt{
+				{0, input.KEY_SPACE, 1},
+				{1 * ms, input.KEY_SPACE, 0},
+				{2 * ms, input.KEY_SPACE, 1},
+			},
+			exp: []keyAt{
+				{0, input.KEY_SPACE, 1},
+				{1 * ms, input.KEY_SPACE, 0},
+				{2 * ms, input.KEY_SPACE, 1},
+			},
+		},
+	}
+
+	for _, test = range tests {
+		deb = pipeline.NewDebouncer(test.opts)
+
+		got = debounce(deb, test.steps, time.Second)
+		if !slices.Equal(got, test.exp) {
+			t.Errorf("%s: got: %v, exp: %v", test.name, got, test.exp)
+		}
+
+		if deb.Stats().Suppressed != test.suppressed {
+			t.Errorf(
+				"%s: suppressed: got: %d, exp: %d",
+				test.name,
+				deb.Stats().Suppressed,
+				test.suppressed,
+			)
+		}
+	}
+}
+
+func TestChainFlush(t *testing.T) {
+	var (
+		chain pipeline.Chain
+		start time.Time
+		due   time.Time
+		frame []input.Event
+		ok    bool
+	)
+
+	t.Parallel()
+
+	chain = pipeline.Chain{
+		pipeline.NewDebouncer(pipeline.DebounceOptions{
+			Mode:      pipeline.DebounceDeferred,
+			Threshold: 10 * time.Millisecond,
+		}),
+		pipeline.Remap(map[pipeline.Code]pipeline.Code{
+			pipeline.Key(input.KEY_A): pipeline.Key(input.KEY_B),
+		}),
+	}
+	start = time.Unix(1000, 0)
+
+	frame = chain.Filter([]input.Event{
+		{Time: input.NewEventTime(start), Type: input.EV_KEY, Code: uint16(input.KEY_A), Value: 1},
+		report(),
+	})
+	if frame != nil {
+		t.Errorf("held frame: got: %v, exp: nil", frame)
+	}
+
+	due, ok = chain.Pending()
+	if !ok || !due.Equal(start.Add(10*time.Millisecond)) {
+		t.Errorf("pending: got: %v %t, exp: %v true", due, ok, start.Add(10*time.Millisecond))
+	}
+
+	frame = chain.Flush(due)
+	if len(frame) != 2 || frame[0].Code != uint16(input.KEY_B) || frame[0].Value != 1 {
+		t.Errorf("flushed frame: got: %v, exp: KEY_B press", frame)
+	}
+}
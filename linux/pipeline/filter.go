@@ -0,0 +1,258 @@
+package pipeline
+
+import (
+	"math"
+	"slices"
+	"time"
+
+	"github.com/andrieee44/gopkg/linux/uapi/input"
+)
+
+// Code identifies an event code of a given type, such as [input.KEY_A]
+// of [input.EV_KEY].
+type Code struct {
+	// Type is the event type.
+	Type input.EventCode
+
+	// Code is the code within Type.
+	Code uint16
+}
+
+// Key returns the [Code] of key.
+func Key(key input.KeyCode) Code {
+	return Code{Type: input.EV_KEY, Code: uint16(key)}
+}
+
+// Rel returns the [Code] of the relative axis rel.
+func Rel(rel input.RelativeCode) Code {
+	return Code{Type: input.EV_REL, Code: uint16(rel)}
+}
+
+// Abs returns the [Code] of the absolute axis abs.
+func Abs(abs input.AbsoluteCode) Code {
+	return Code{Type: input.EV_ABS, Code: uint16(abs)}
+}
+
+// CodeOf returns the [Code] of event.
+func CodeOf(event input.Event) Code {
+	return Code{Type: event.Type, Code: event.Code}
+}
+
+// Remap returns a [Filter] replacing the type and code of events found in
+// codes, such as [input.KEY_CAPSLOCK] with [input.KEY_ESC]. Values are
+// kept, so codes should map to codes of the same kind. The sink must
+// support the new codes.
+func Remap(codes map[Code]Code) Filter {
+	return FilterFunc(func(frame []input.Event) []input.Event {
+		var (
+			idx int
+			to  Code
+			ok  bool
+		)
+
+		for idx = range frame {
+			to, ok = codes[CodeOf(frame[idx])]
+			if ok {
+				frame[idx].Type, frame[idx].Code = to.Type, to.Code
+			}
+		}
+
+		return frame
+	})
+}
+
+// Drop returns a [Filter] removing the events of codes.
+func Drop(codes ...Code) Filter {
+	return dropIf(func(event input.Event) bool {
+		return slices.Contains(codes, CodeOf(event))
+	})
+}
+
+// DropTypes returns a [Filter] removing the events of types, such as
+// [input.EV_MSC].
+func DropTypes(types ...input.EventCode) Filter {
+	return dropIf(func(event input.Event) bool {
+		return slices.Contains(types, event.Type)
+	})
+}
+
+// SwapAxes returns a [Filter] exchanging the events of the axes a and b,
+// such as [input.REL_X] and [input.REL_Y] to turn a trackball on its
+// side. Absolute axes should share a range.
+func SwapAxes(a, b Code) Filter {
+	return Remap(map[Code]Code{a: b, b: a})
+}
+
+// Invert returns a [Filter] negating the values of the relative axes
+// codes. Use [InvertAbsolute] for absolute axes.
+func Invert(codes ...Code) Filter {
+	return FilterFunc(func(frame []input.Event) []input.Event {
+		var idx int
+
+		for idx = range frame {
+			if slices.Contains(codes, CodeOf(frame[idx])) {
+				frame[idx].Value = -frame[idx].Value
+			}
+		}
+
+		return frame
+	})
+}
+
+// InvertAbsolute returns a [Filter] mirroring the values of the absolute
+// axis abs within its range, given by info.
+func InvertAbsolute(abs input.AbsoluteCode, info input.AbsInfo) Filter {
+	return FilterFunc(func(frame []input.Event) []input.Event {
+		var idx int
+
+		for idx = range frame {
+			if CodeOf(frame[idx]) == Abs(abs) {
+				frame[idx].Value = info.Minimum + info.Maximum - frame[idx].Value
+			}
+		}
+
+		return frame
+	})
+}
+
+// Scale returns a [Filter] multiplying the values of the relative axis
+// code by factor. The fractional part of each scaled value is carried to
+// the next event, so slow motion is not lost, and events scaled to zero
+// are dropped. Use [ScaleAbsolute] for absolute axes.
+func Scale(code Code, factor float64) Filter {
+	var rem float64
+
+	return FilterFunc(func(frame []input.Event) []input.Event {
+		var (
+			out    []input.Event
+			event  input.Event
+			scaled float64
+		)
+
+		out = frame[:0]
+
+		for _, event = range frame {
+			if CodeOf(event) == code {
+				scaled = float64(event.Value)*factor + rem
+				event.Value = int32(math.Trunc(scaled))
+				rem = scaled - float64(event.Value)
+
+				if event.Value == 0 {
+					continue
+				}
+			}
+
+			out = append(out, event)
+		}
+
+		return out
+	})
+}
+
+// ScaleAbsolute returns a [Filter] mapping the values of the absolute
+// axis abs from the range of from to the range of to, such as to fit a
+// tablet to the area of a sink with different resolution.
+func ScaleAbsolute(abs input.AbsoluteCode, from, to input.AbsInfo) Filter {
+	var fromSpan, toSpan float64
+
+	fromSpan = float64(from.Maximum) - float64(from.Minimum)
+	toSpan = float64(to.Maximum) - float64(to.Minimum)
+
+	return FilterFunc(func(frame []input.Event) []input.Event {
+		var (
+			idx      int
+			position float64
+		)
+
+		if fromSpan == 0 {
+			return frame
+		}
+
+		for idx = range frame {
+			if CodeOf(frame[idx]) != Abs(abs) {
+				continue
+			}
+
+			position = (float64(frame[idx].Value) - float64(from.Minimum)) / fromSpan
+			frame[idx].Value = int32(math.Round(float64(to.Minimum) + position*toSpan))
+		}
+
+		return frame
+	})
+}
+
+// RateLimit returns a [Filter] passing at most one event of each of codes
+// per interval, going by event timestamps, and dropping the others. It
+// calms noisy axes and chattering switches. Keys are tracked so presses
+// and releases stay paired: the release of a passed press is always
+// passed, and that of a dropped press, or a repeat of it, is dropped.
+func RateLimit(interval time.Duration, codes ...Code) Filter {
+	var (
+		last map[Code]time.Time
+		held map[Code]bool
+	)
+
+	last = make(map[Code]time.Time)
+	held = make(map[Code]bool)
+
+	return dropIf(func(event input.Event) bool {
+		var (
+			code   Code
+			at     time.Time
+			prev   time.Time
+			passed bool
+			ok     bool
+		)
+
+		code = CodeOf(event)
+		if !slices.Contains(codes, code) {
+			return false
+		}
+
+		if event.Type == input.EV_KEY && event.Value == 0 {
+			passed = held[code]
+			delete(held, code)
+
+			return !passed
+		}
+
+		if event.Type == input.EV_KEY && event.Value != 1 && !held[code] {
+			return true
+		}
+
+		at = event.Time.Time()
+
+		prev, ok = last[code]
+		if ok && at.Sub(prev) < interval {
+			return true
+		}
+
+		last[code] = at
+
+		if event.Type == input.EV_KEY && event.Value == 1 {
+			held[code] = true
+		}
+
+		return false
+	})
+}
+
+// dropIf returns a [Filter] removing the events drop returns true for.
+func dropIf(drop func(event input.Event) bool) Filter {
+	return FilterFunc(func(frame []input.Event) []input.Event {
+		var (
+			out   []input.Event
+			event input.Event
+		)
+
+		out = frame[:0]
+
+		for _, event = range frame {
+			if event.Type == input.EV_SYN || !drop(event) {
+				out = append(out, event)
+			}
+		}
+
+		return out
+	})
+}
@@ -0,0 +1,361 @@
+// Package pipeline connects an evdev device to a uinput device through a
+// chain of filters working on whole frames of events. It provides the
+// read-transform-write loop every remapping tool needs, together with
+// filters to remap and drop codes, swap, invert and scale axes, rate
+// limit noisy codes and debounce chattering keys.
+//
+// A frame is a batch of events ending with [input.SYN_REPORT], the unit
+// in which the kernel delivers input. The filters of the pointer package,
+// such as its Accelerator, work on the same frames and can be chained too.
+package pipeline
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"maps"
+	"slices"
+	"time"
+
+	"github.com/andrieee44/gopkg/linux/evdev"
+	"github.com/andrieee44/gopkg/linux/uapi/input"
+	"github.com/andrieee44/gopkg/linux/uinput"
+)
+
+// Filter transforms frames of events.
+type Filter interface {
+	// Filter returns the frame to pass on in place of frame, a batch of
+	// events ending with [input.SYN_REPORT]. It may modify and return
+	// frame itself. Returning a frame without events other than
+	// synchronisation events drops it.
+	Filter(frame []input.Event) []input.Event
+}
+
+// Deferrer is a [Filter] that holds events back until a later time, such
+// as a [Debouncer] waiting for a key to settle. [Chain.Run] flushes it
+// when its events are due. Times are on the clock of the timestamps of
+// the events filtered, which is the realtime clock unless the source was
+// set to another with [evdev.Device.SetClockID].
+type Deferrer interface {
+	Filter
+
+	// Pending returns the time the earliest held event is due, and
+	// whether any event is held.
+	Pending() (time.Time, bool)
+
+	// Flush returns a frame with the held events due at now, or nil if
+	// there are none.
+	Flush(now time.Time) []input.Event
+}
+
+// FilterFunc adapts a function to a [Filter].
+type FilterFunc func(frame []input.Event) []input.Event
+
+// Chain is a [Filter] passing frames through its filters in order. It is
+// a [Deferrer] for the deferring filters it holds.
+type Chain []Filter
+
+// Filter calls fn.
+func (fn FilterFunc) Filter(frame []input.Event) []input.Event {
+	return fn(frame)
+}
+
+// Filter passes frame through every filter of the chain in order,
+// stopping early when one drops it.
+func (chain Chain) Filter(frame []input.Event) []input.Event {
+	var filter Filter
+
+	for _, filter = range chain {
+		frame = filter.Filter(frame)
+		if !hasEvents(frame) {
+			return nil
+		}
+	}
+
+	return frame
+}
+
+// Pending returns the earliest time a [Deferrer] of the chain has events
+// due, and whether any has.
+func (chain Chain) Pending() (time.Time, bool) {
+	var (
+		filter   Filter
+		deferrer Deferrer
+		earliest time.Time
+		due      time.Time
+		found    bool
+		ok       bool
+	)
+
+	for _, filter = range chain {
+		deferrer, ok = filter.(Deferrer)
+		if !ok {
+			continue
+		}
+
+		due, ok = deferrer.Pending()
+		if ok && (!found || due.Before(earliest)) {
+			earliest, found = due, true
+		}
+	}
+
+	return earliest, found
+}
+
+// Flush flushes every [Deferrer] of the chain, passing the events each
+// returns through the filters after it, and returns them as one frame, or
+// nil if there are none.
+func (chain Chain) Flush(now time.Time) []input.Event {
+	var (
+		out      []input.Event
+		frame    []input.Event
+		event    input.Event
+		deferrer Deferrer
+		idx      int
+		ok       bool
+	)
+
+	for idx = range chain {
+		deferrer, ok = chain[idx].(Deferrer)
+		if !ok {
+			continue
+		}
+
+		frame = deferrer.Flush(now)
+		if !hasEvents(frame) {
+			continue
+		}
+
+		frame = chain[idx+1:].Filter(frame)
+
+		for _, event = range frame {
+			if event.Type != input.EV_SYN {
+				out = append(out, event)
+			}
+		}
+	}
+
+	if len(out) == 0 {
+		return nil
+	}
+
+	return append(out, input.Event{
+		Time: input.NewEventTime(now),
+		Type: input.EV_SYN,
+		Code: uint16(input.SYN_REPORT),
+	})
+}
+
+// Feed filters frame and emits what is left to sink, unless the chain
+// drops it.
+func (chain Chain) Feed(sink uinput.Emitter, frame []input.Event) error {
+	return chain.emit(sink, chain.Filter(frame))
+}
+
+// emit passes frame to sink unless it holds no events.
+func (chain Chain) emit(sink uinput.Emitter, frame []input.Event) error {
+	var err error
+
+	if !hasEvents(frame) {
+		return nil
+	}
+
+	err = sink.EmitBatch(frame)
+	if err != nil {
+		return fmt.Errorf("failed to emit filtered frame: %w", err)
+	}
+
+	return nil
+}
+
+// Run reads frames from source, passes them through the chain and emits
+// them to sink, such as a [uinput.Device] made from a snapshot of source,
+// until ctx is cancelled or source or sink fails. After source drops
+// events, its events up to and including the next [input.SYN_REPORT] are
+// discarded, and the keys pressed on source are then read with
+// [evdev.Device.EnabledKeycodes] to pass the presses and releases missed
+// through the chain as one frame. Run starts [evdev.Device.ReadEvents] on
+// source, so it must not be read elsewhere; grab it with
+// [evdev.Device.Grab] to hide its own events from applications. Events
+// held by a [Deferrer] are emitted when due, going by the clock of the
+// timestamps of source, as told by the time each event is read, so any
+// clock set with [evdev.Device.SetClockID] works. Run returns ctx's error
+// on cancellation; source reaching end of file is reported as [io.EOF].
+func (chain Chain) Run(ctx context.Context, source *evdev.Device, sink uinput.Emitter) error {
+	var (
+		eventsChan <-chan input.Event
+		errChan    <-chan error
+		event      input.Event
+		frame      []input.Event
+		timer      *time.Timer
+		timerChan  <-chan time.Time
+		now        time.Time
+		offset     time.Duration
+		held       map[input.KeyCode]bool
+		pressed    []input.KeyCode
+		dropped    bool
+		ok         bool
+		err        error
+	)
+
+	held = make(map[input.KeyCode]bool)
+	eventsChan, errChan = source.ReadEvents()
+	timer = time.NewTimer(0)
+	timer.Stop()
+
+	defer timer.Stop()
+
+	for {
+		timerChan = chain.schedule(timer, offset)
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case now = <-timerChan:
+			err = chain.emit(sink, chain.Flush(now.Add(offset)))
+			if err != nil {
+				return err
+			}
+
+			continue
+		case err, ok = <-errChan:
+			if !ok {
+				err = io.EOF
+			}
+
+			return fmt.Errorf("%s: failed to read events: %w", source.Filename(), err)
+		case event, ok = <-eventsChan:
+			if !ok {
+				return fmt.Errorf("%s: failed to read events: %w", source.Filename(), io.EOF)
+			}
+
+			offset = event.Time.Time().Sub(time.Now())
+		}
+
+		if event.Type != input.EV_SYN {
+			if !dropped {
+				frame = append(frame, event)
+			}
+
+			continue
+		}
+
+		switch input.SyncCode(event.Code) {
+		case input.SYN_DROPPED:
+			frame, dropped = nil, true
+		case input.SYN_REPORT:
+			if dropped {
+				frame, dropped = nil, false
+
+				pressed, err = source.EnabledKeycodes()
+				if err != nil {
+					return fmt.Errorf("failed to resync %s: %w", source.Filename(), err)
+				}
+
+				frame = resyncFrame(held, pressed, event)
+			} else {
+				frame = append(frame, event)
+			}
+
+			trackKeys(held, frame)
+
+			err = chain.Feed(sink, frame)
+			if err != nil {
+				return err
+			}
+
+			frame = nil
+		default:
+			frame = append(frame, event)
+		}
+	}
+}
+
+// trackKeys records the keys frame presses and releases in held.
+func trackKeys(held map[input.KeyCode]bool, frame []input.Event) {
+	var event input.Event
+
+	for _, event = range frame {
+		if event.Type != input.EV_KEY {
+			continue
+		}
+
+		switch event.Value {
+		case 0:
+			delete(held, input.KeyCode(event.Code))
+		case 1:
+			held[input.KeyCode(event.Code)] = true
+		}
+	}
+}
+
+// resyncFrame returns a frame, ended by report, releasing the keys of held
+// that are not in pressed and pressing those of pressed not in held.
+func resyncFrame(
+	held map[input.KeyCode]bool,
+	pressed []input.KeyCode,
+	report input.Event,
+) []input.Event {
+	var (
+		frame []input.Event
+		key   input.KeyCode
+	)
+
+	for _, key = range slices.Sorted(maps.Keys(held)) {
+		if !slices.Contains(pressed, key) {
+			frame = append(frame, timedKeyEvent(report.Time, key, 0))
+		}
+	}
+
+	for _, key = range pressed {
+		if !held[key] {
+			frame = append(frame, timedKeyEvent(report.Time, key, 1))
+		}
+	}
+
+	return append(frame, report)
+}
+
+func timedKeyEvent(at input.EventTime, key input.KeyCode, value int32) input.Event {
+	var event input.Event
+
+	event = uinput.KeyEvent(key, value)
+	event.Time = at
+
+	return event
+}
+
+// schedule sets timer to fire when the chain's held events are due and
+// returns its channel, or nil if none are held. offset is the time the
+// clock of the events is ahead of the realtime clock.
+func (chain Chain) schedule(timer *time.Timer, offset time.Duration) <-chan time.Time {
+	var (
+		due time.Time
+		ok  bool
+	)
+
+	due, ok = chain.Pending()
+	if !ok {
+		timer.Stop()
+
+		return nil
+	}
+
+	timer.Reset(time.Until(due.Add(-offset)))
+
+	return timer.C
+}
+
+// hasEvents reports whether frame holds an event other than a
+// synchronisation event.
+func hasEvents(frame []input.Event) bool {
+	var event input.Event
+
+	for _, event = range frame {
+		if event.Type != input.EV_SYN {
+			return true
+		}
+	}
+
+	return false
+}
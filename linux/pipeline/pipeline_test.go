@@ -0,0 +1,232 @@
+package pipeline_test
+
+import (
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/andrieee44/gopkg/linux/pipeline"
+	"github.com/andrieee44/gopkg/linux/uapi/input"
+	"github.com/andrieee44/gopkg/linux/uinput/uinputtest"
+)
+
+func event(typ input.EventCode, code uint16, value int32) input.Event {
+	return input.Event{Type: typ, Code: code, Value: value}
+}
+
+func report() input.Event {
+	return event(input.EV_SYN, uint16(input.SYN_REPORT), 0)
+}
+
+func TestFilters(t *testing.T) {
+	type table struct {
+		name   string
+		filter pipeline.Filter
+		frame  []input.Event
+		exp    []input.Event
+	}
+
+	var (
+		tests []table
+		test  table
+		got   []input.Event
+	)
+
+	t.Parallel()
+
+	tests = []table{
+		{
+			name: "remap",
+			filter: pipeline.Remap(map[pipeline.Code]pipeline.Code{
+				pipeline.Key(input.KEY_CAPSLOCK): pipeline.Key(input.KEY_ESC),
+			}),
+			frame: []input.Event{
+				event(input.EV_KEY, uint16(input.KEY_CAPSLOCK), 1),
+				event(input.EV_KEY, uint16(input.KEY_A), 1),
+				report(),
+			},
+			exp: []input.Event{
+				event(input.EV_KEY, uint16(input.KEY_ESC), 1),
+				event(input.EV_KEY, uint16(input.KEY_A), 1),
+				report(),
+			},
+		},
+		{
+			name:   "drop",
+			filter: pipeline.Drop(pipeline.Rel(input.REL_WHEEL)),
+			frame: []input.Event{
+				event(input.EV_REL, uint16(input.REL_WHEEL), 1),
+				event(input.EV_REL, uint16(input.REL_X), 2),
+				report(),
+			},
+			exp: []input.Event{event(input.EV_REL, uint16(input.REL_X), 2), report()},
+		},
+		{
+			name:   "drop types",
+			filter: pipeline.DropTypes(input.EV_MSC),
+			frame:  []input.Event{event(input.EV_MSC, uint16(input.MSC_SCAN), 30), report()},
+			exp:    []input.Event{report()},
+		},
+		{
+			name:   "swap axes",
+			filter: pipeline.SwapAxes(pipeline.Rel(input.REL_X), pipeline.Rel(input.REL_Y)),
+			frame: []input.Event{
+				event(input.EV_REL, uint16(input.REL_X), 1),
+				event(input.EV_REL, uint16(input.REL_Y), 2),
+				report(),
+			},
+			exp: []input.Event{
+				event(input.EV_REL, uint16(input.REL_Y), 1),
+				event(input.EV_REL, uint16(input.REL_X), 2),
+				report(),
+			},
+		},
+		{
+			name:   "invert",
+			filter: pipeline.Invert(pipeline.Rel(input.REL_WHEEL)),
+			frame:  []input.Event{event(input.EV_REL, uint16(input.REL_WHEEL), 1), report()},
+			exp:    []input.Event{event(input.EV_REL, uint16(input.REL_WHEEL), -1), report()},
+		},
+		{
+			name: "invert absolute",
+			filter: pipeline.InvertAbsolute(
+				input.ABS_Y,
+				input.AbsInfo{Minimum: 0, Maximum: 255},
+			),
+			frame: []input.Event{event(input.EV_ABS, uint16(input.ABS_Y), 55), report()},
+			exp:   []input.Event{event(input.EV_ABS, uint16(input.ABS_Y), 200), report()},
+		},
+		{
+			name:   "scale",
+			filter: pipeline.Scale(pipeline.Rel(input.REL_X), 0.5),
+			frame: []input.Event{
+				event(input.EV_REL, uint16(input.REL_X), 5),
+				event(input.EV_REL, uint16(input.REL_X), 1),
+				event(input.EV_REL, uint16(input.REL_X), 1),
+				report(),
+			},
+			exp: []input.Event{
+				event(input.EV_REL, uint16(input.REL_X), 2),
+				event(input.EV_REL, uint16(input.REL_X), 1),
+				report(),
+			},
+		},
+		{
+			name: "scale absolute",
+			filter: pipeline.ScaleAbsolute(
+				input.ABS_X,
+				input.AbsInfo{Minimum: 0, Maximum: 100},
+				input.AbsInfo{Minimum: -1000, Maximum: 1000},
+			),
+			frame: []input.Event{event(input.EV_ABS, uint16(input.ABS_X), 75), report()},
+			exp:   []input.Event{event(input.EV_ABS, uint16(input.ABS_X), 500), report()},
+		},
+	}
+
+	for _, test = range tests {
+		got = test.filter.Filter(test.frame)
+		if !slices.Equal(got, test.exp) {
+			t.Errorf("%s: got: %v, exp: %v", test.name, got, test.exp)
+		}
+	}
+}
+
+func TestRateLimit(t *testing.T) {
+	var (
+		filter pipeline.Filter
+		start  time.Time
+		offset time.Duration
+		got    []int32
+		frame  []input.Event
+		ev     input.Event
+	)
+
+	t.Parallel()
+
+	filter = pipeline.RateLimit(10*time.Millisecond, pipeline.Abs(input.ABS_X))
+	start = time.Unix(1000, 0)
+
+	for _, offset = range []time.Duration{0, 4, 9, 10, 15, 25} {
+		ev = event(input.EV_ABS, uint16(input.ABS_X), int32(offset))
+		ev.Time = input.NewEventTime(start.Add(offset * time.Millisecond))
+
+		for _, ev = range filter.Filter([]input.Event{ev, report()}) {
+			if ev.Type == input.EV_ABS {
+				got = append(got, ev.Value)
+			}
+		}
+	}
+
+	frame = filter.Filter([]input.Event{event(input.EV_ABS, uint16(input.ABS_Y), 1), report()})
+	if len(frame) != 2 {
+		t.Errorf("unlimited code: got: %v, exp: passed", frame)
+	}
+
+	if !slices.Equal(got, []int32{0, 10, 25}) {
+		t.Errorf("got: %v, exp: %v", got, []int32{0, 10, 25})
+	}
+}
+
+func TestRateLimitKeys(t *testing.T) {
+	var (
+		filter pipeline.Filter
+		start  time.Time
+		step   [2]int32
+		got    []int32
+		ev     input.Event
+		exp    []int32
+	)
+
+	t.Parallel()
+
+	filter = pipeline.RateLimit(10*time.Millisecond, pipeline.Key(input.KEY_A))
+	start = time.Unix(1000, 0)
+
+	for _, step = range [][2]int32{{0, 1}, {2, 0}, {4, 1}, {5, 2}, {6, 0}, {12, 1}, {14, 0}} {
+		ev = event(input.EV_KEY, uint16(input.KEY_A), step[1])
+		ev.Time = input.NewEventTime(start.Add(time.Duration(step[0]) * time.Millisecond))
+
+		for _, ev = range filter.Filter([]input.Event{ev, report()}) {
+			if ev.Type == input.EV_KEY {
+				got = append(got, ev.Value)
+			}
+		}
+	}
+
+	exp = []int32{1, 0, 1, 0}
+	if !slices.Equal(got, exp) {
+		t.Errorf("got: %v, exp: %v", got, exp)
+	}
+}
+
+func TestChain(t *testing.T) {
+	var (
+		rec   *uinputtest.Recorder
+		chain pipeline.Chain
+		err   error
+	)
+
+	t.Parallel()
+
+	rec = new(uinputtest.Recorder)
+	chain = pipeline.Chain{
+		pipeline.Remap(map[pipeline.Code]pipeline.Code{
+			pipeline.Key(input.KEY_A): pipeline.Key(input.KEY_B),
+		}),
+		pipeline.Drop(pipeline.Key(input.KEY_B)),
+	}
+
+	err = chain.Feed(rec, []input.Event{event(input.EV_KEY, uint16(input.KEY_A), 1), report()})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = chain.Feed(rec, []input.Event{event(input.EV_KEY, uint16(input.KEY_C), 1), report()})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(rec.Batches()) != 1 || rec.Batches()[0][0].Code != uint16(input.KEY_C) {
+		t.Errorf("got: %v, exp: only the KEY_C frame", rec.Batches())
+	}
+}
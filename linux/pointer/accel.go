@@ -0,0 +1,205 @@
+// Package pointer reshapes relative pointer motion read from evdev
+// devices. It provides acceleration profiles for [input.REL_X] and
+// [input.REL_Y] streams that use event timestamps to estimate velocity,
+// carries sub-pixel remainders between events so slow motion is not lost,
+// accumulates high-resolution wheel motion into legacy wheel detents, and
+// turns motion into scrolling while a button is held.
+//
+// It is intended for proxies that read a mouse with exclusive access and
+// re-emit its events through uinput with a different feel.
+package pointer
+
+import (
+	"math"
+	"time"
+
+	"github.com/andrieee44/gopkg/linux/uapi/input"
+)
+
+// DefaultDPI is the resolution profiles are normalised to.
+const DefaultDPI = 1000
+
+const (
+	// velocityWindow bounds how far back motion samples contribute to
+	// the velocity estimate.
+	velocityWindow = 50 * time.Millisecond
+
+	// motionTimeout is the pause after which motion is treated as a new
+	// movement starting from rest.
+	motionTimeout = time.Second
+
+	// defaultInterval is the assumed time since the previous event for
+	// the first event of a movement, roughly one report at 125 Hz.
+	defaultInterval = 8 * time.Millisecond
+)
+
+// Accelerator applies a [Profile] to relative motion deltas. It tracks
+// recent motion to estimate velocity and keeps the fractional part of
+// each accelerated delta so that it contributes to later events. The zero
+// value is not usable; create one with [NewAccelerator].
+type Accelerator struct {
+	profile    Profile
+	dpi        float64
+	samples    []motionSample
+	last       time.Time
+	remX, remY float64
+}
+
+type motionSample struct {
+	dist  float64
+	start time.Time
+}
+
+// NewAccelerator returns an [Accelerator] using profile for a device of
+// [DefaultDPI].
+func NewAccelerator(profile Profile) *Accelerator {
+	return &Accelerator{
+		profile: profile,
+		dpi:     DefaultDPI,
+	}
+}
+
+// SetDPI sets the resolution of the source device, used to normalise
+// velocity. Non-positive values are ignored.
+func (acc *Accelerator) SetDPI(dpi float64) {
+	if dpi > 0 {
+		acc.dpi = dpi
+	}
+}
+
+// Reset discards the velocity history and sub-pixel remainders.
+func (acc *Accelerator) Reset() {
+	acc.samples = acc.samples[:0]
+	acc.last = time.Time{}
+	acc.remX = 0
+	acc.remY = 0
+}
+
+// Velocity records a motion of dx, dy device units at time at and
+// returns the estimated velocity in units per millisecond at 1000 DPI.
+func (acc *Accelerator) Velocity(dx, dy int32, at time.Time) float64 {
+	var (
+		dist, total float64
+		elapsed     time.Duration
+		sample      motionSample
+		keep        int
+	)
+
+	if acc.last.IsZero() || at.Before(acc.last) || at.Sub(acc.last) > motionTimeout {
+		acc.samples = acc.samples[:0]
+		acc.last = at.Add(-defaultInterval)
+	}
+
+	dist = math.Hypot(float64(dx), float64(dy)) * DefaultDPI / acc.dpi
+	acc.samples = append(acc.samples, motionSample{dist: dist, start: acc.last})
+	acc.last = at
+
+	for keep = 0; keep < len(acc.samples)-1; keep++ {
+		if at.Sub(acc.samples[keep].start) <= velocityWindow {
+			break
+		}
+	}
+
+	acc.samples = append(acc.samples[:0], acc.samples[keep:]...)
+
+	for _, sample = range acc.samples {
+		total += sample.dist
+	}
+
+	elapsed = at.Sub(acc.samples[0].start)
+	if elapsed <= 0 {
+		elapsed = defaultInterval
+	}
+
+	return total / (float64(elapsed) / float64(time.Millisecond))
+}
+
+// Accelerate records a motion of dx, dy device units at time at and
+// returns the accelerated deltas. The fractional part of each result is
+// carried over to the next call.
+func (acc *Accelerator) Accelerate(dx, dy int32, at time.Time) (int32, int32) {
+	var factor, x, y float64
+
+	factor = acc.profile.Factor(acc.Velocity(dx, dy, at))
+
+	x = float64(dx)*factor + acc.remX
+	y = float64(dy)*factor + acc.remY
+
+	acc.remX = x - math.Trunc(x)
+	acc.remY = y - math.Trunc(y)
+
+	return int32(math.Trunc(x)), int32(math.Trunc(y))
+}
+
+// Filter rewrites the [input.REL_X] and [input.REL_Y] events of frame,
+// a batch of events ending with [input.SYN_REPORT], using the timestamp
+// of the report for velocity. Accelerated deltas that round to zero are
+// dropped; all other events pass through unchanged.
+func (acc *Accelerator) Filter(frame []input.Event) []input.Event {
+	var (
+		out        []input.Event
+		event      input.Event
+		dx, dy     int32
+		at         time.Time
+		rel        input.RelativeCode
+		hasMotion  bool
+		syncEvents []input.Event
+	)
+
+	out = make([]input.Event, 0, len(frame))
+
+	for _, event = range frame {
+		at = event.Time.Time()
+
+		if event.Type == input.EV_SYN {
+			syncEvents = append(syncEvents, event)
+
+			continue
+		}
+
+		if event.Type != input.EV_REL {
+			out = append(out, event)
+
+			continue
+		}
+
+		rel = input.RelativeCode(event.Code)
+
+		switch rel {
+		case input.REL_X:
+			dx += event.Value
+			hasMotion = true
+		case input.REL_Y:
+			dy += event.Value
+			hasMotion = true
+		default:
+			out = append(out, event)
+		}
+	}
+
+	if hasMotion {
+		dx, dy = acc.Accelerate(dx, dy, at)
+		out = appendRel(out, at, input.REL_X, dx)
+		out = appendRel(out, at, input.REL_Y, dy)
+	}
+
+	return append(out, syncEvents...)
+}
+
+func appendRel(
+	events []input.Event,
+	at time.Time,
+	code input.RelativeCode,
+	value int32,
+) []input.Event {
+	if value == 0 {
+		return events
+	}
+
+	return append(events, input.Event{
+		Time:  input.NewEventTime(at),
+		Type:  input.EV_REL,
+		Code:  uint16(code),
+		Value: value,
+	})
+}
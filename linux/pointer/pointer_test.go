@@ -0,0 +1,393 @@
+package pointer_test
+
+import (
+	"errors"
+	"math"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/andrieee44/gopkg/linux/pipeline"
+	"github.com/andrieee44/gopkg/linux/pointer"
+	"github.com/andrieee44/gopkg/linux/uapi/input"
+)
+
+func TestProfiles(t *testing.T) {
+	type table struct {
+		name     string
+		profile  pointer.Profile
+		velocity float64
+		exp      float64
+	}
+
+	var (
+		curve *pointer.CurveProfile
+		tests []table
+		test  table
+		got   float64
+		err   error
+	)
+
+	t.Parallel()
+
+	curve, err = pointer.NewCurveProfile([]pointer.CurvePoint{
+		{Velocity: 0, Factor: 0.5},
+		{Velocity: 1, Factor: 1},
+		{Velocity: 3, Factor: 2},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests = []table{
+		{"flat", pointer.FlatProfile{}, 5, 1},
+		{"flat fast", pointer.FlatProfile{Speed: 0.5}, 0, 1.5},
+		{"flat clamped", pointer.FlatProfile{Speed: 3}, 0, 2},
+		{"adaptive slow", pointer.AdaptiveProfile{}, 0.05, 0.5},
+		{"adaptive unity", pointer.AdaptiveProfile{}, 0.4, 1},
+		{"adaptive max", pointer.AdaptiveProfile{}, 10, 2},
+		{"curve start", curve, 0, 0.5},
+		{"curve mid", curve, 2, 1.5},
+		{"curve end", curve, 10, 2},
+	}
+
+	for _, test = range tests {
+		got = test.profile.Factor(test.velocity)
+		if math.Abs(got-test.exp) > 1e-9 {
+			t.Errorf("%s: got: %f, exp: %f", test.name, got, test.exp)
+		}
+	}
+}
+
+func TestNewCurveProfileInvalid(t *testing.T) {
+	var (
+		curves [][]pointer.CurvePoint
+		points []pointer.CurvePoint
+		err    error
+	)
+
+	t.Parallel()
+
+	curves = [][]pointer.CurvePoint{
+		nil,
+		{{Velocity: 1, Factor: 1}, {Velocity: 1, Factor: 2}},
+		{{Velocity: -1, Factor: 1}},
+	}
+
+	for _, points = range curves {
+		_, err = pointer.NewCurveProfile(points)
+		if !errors.Is(err, pointer.ErrInvalidCurve) {
+			t.Errorf("%v: got: %v, exp: %v", points, err, pointer.ErrInvalidCurve)
+		}
+	}
+}
+
+func TestAcceleratorRemainder(t *testing.T) {
+	type table struct {
+		name       string
+		steps      [][2]int32
+		expX, expY int32
+	}
+
+	var (
+		acc        *pointer.Accelerator
+		at         time.Time
+		tests      []table
+		test       table
+		step       [2]int32
+		idx        int
+		dx, dy     int32
+		sumX, sumY int32
+	)
+
+	t.Parallel()
+
+	tests = []table{
+		{
+			name:  "x only",
+			steps: slices.Repeat([][2]int32{{1, 0}}, 8),
+			expX:  2,
+		},
+		{
+			name:  "alternating axes",
+			steps: slices.Repeat([][2]int32{{1, 0}, {0, 1}}, 8),
+			expX:  2,
+			expY:  2,
+		},
+	}
+
+	for _, test = range tests {
+		acc = pointer.NewAccelerator(pointer.FlatProfile{Speed: -0.75})
+		at = time.Unix(0, 0)
+		sumX, sumY = 0, 0
+
+		for idx, step = range test.steps {
+			at = at.Add(time.Duration(idx+1) * time.Millisecond)
+			dx, dy = acc.Accelerate(step[0], step[1], at)
+			sumX += dx
+			sumY += dy
+		}
+
+		if sumX != test.expX || sumY != test.expY {
+			t.Errorf("%s: got: %d, %d, exp: %d, %d", test.name, sumX, sumY, test.expX, test.expY)
+		}
+	}
+}
+
+func TestAcceleratorFilter(t *testing.T) {
+	var (
+		acc   *pointer.Accelerator
+		frame []input.Event
+		out   []input.Event
+	)
+
+	t.Parallel()
+
+	acc = pointer.NewAccelerator(pointer.FlatProfile{Speed: 1})
+	frame = []input.Event{
+		{Type: input.EV_REL, Code: uint16(input.REL_X), Value: 3},
+		{Type: input.EV_KEY, Code: uint16(input.BTN_LEFT), Value: 1},
+		{Type: input.EV_SYN, Code: uint16(input.SYN_REPORT)},
+	}
+
+	out = acc.Filter(frame)
+	if len(out) != 3 ||
+		out[0].Type != input.EV_KEY ||
+		out[1].Code != uint16(input.REL_X) || out[1].Value != 6 ||
+		out[2].Type != input.EV_SYN {
+		t.Errorf("got: %+v", out)
+	}
+}
+
+func TestWheel(t *testing.T) {
+	type table struct {
+		value          int32
+		hiRes, detents int32
+	}
+
+	var (
+		wheel          pointer.Wheel
+		tests          []table
+		test           table
+		hiRes, detents int32
+	)
+
+	t.Parallel()
+
+	tests = []table{
+		{60, 60, 0},
+		{60, 60, 1},
+		{90, 90, 0},
+		{-30, -30, 0},
+		{-100, -100, -1},
+		{240, 240, 2},
+	}
+
+	for _, test = range tests {
+		hiRes, detents = wheel.AddHiRes(test.value)
+		if hiRes != test.hiRes || detents != test.detents {
+			t.Errorf(
+				"%d: got: (%d, %d), exp: (%d, %d)",
+				test.value,
+				hiRes,
+				detents,
+				test.hiRes,
+				test.detents,
+			)
+		}
+	}
+}
+
+func TestWheelFilter(t *testing.T) {
+	var (
+		filter pointer.WheelFilter
+		out    []input.Event
+	)
+
+	t.Parallel()
+
+	filter.Vertical.Scale = 0.5
+
+	out = filter.Filter([]input.Event{
+		{Type: input.EV_REL, Code: uint16(input.REL_WHEEL), Value: 1},
+		{Type: input.EV_SYN, Code: uint16(input.SYN_REPORT)},
+	})
+	if len(out) != 2 || out[0].Code != uint16(input.REL_WHEEL_HI_RES) || out[0].Value != 60 {
+		t.Errorf("first frame: got: %+v", out)
+	}
+
+	out = filter.Filter([]input.Event{
+		{Type: input.EV_REL, Code: uint16(input.REL_WHEEL), Value: 1},
+		{Type: input.EV_SYN, Code: uint16(input.SYN_REPORT)},
+	})
+	if len(out) != 3 || out[1].Code != uint16(input.REL_WHEEL) || out[1].Value != 1 {
+		t.Errorf("second frame: got: %+v", out)
+	}
+}
+
+func TestButtonScroll(t *testing.T) {
+	type table struct {
+		name  string
+		frame []input.Event
+		exp   []input.Event
+	}
+
+	var (
+		scroll *pointer.ButtonScroll
+		report input.Event
+		tests  []table
+		test   table
+		got    []input.Event
+	)
+
+	t.Parallel()
+
+	scroll = pointer.NewButtonScroll(pointer.ButtonScrollOptions{
+		Remap: map[input.KeyCode]input.KeyCode{
+			input.BTN_SIDE:  input.BTN_EXTRA,
+			input.BTN_EXTRA: input.BTN_SIDE,
+		},
+	})
+	report = input.Event{Type: input.EV_SYN, Code: uint16(input.SYN_REPORT)}
+
+	tests = []table{
+		{
+			name:  "remap",
+			frame: []input.Event{{Type: input.EV_KEY, Code: uint16(input.BTN_SIDE), Value: 1}, report},
+			exp:   []input.Event{{Type: input.EV_KEY, Code: uint16(input.BTN_EXTRA), Value: 1}, report},
+		},
+		{
+			name:  "press held back",
+			frame: []input.Event{{Type: input.EV_KEY, Code: uint16(input.BTN_MIDDLE), Value: 1}, report},
+			exp:   []input.Event{report},
+		},
+		{
+			name:  "click",
+			frame: []input.Event{{Type: input.EV_KEY, Code: uint16(input.BTN_MIDDLE), Value: 0}, report},
+			exp:   []input.Event{{Type: input.EV_KEY, Code: uint16(input.BTN_MIDDLE), Value: 1}, report},
+		},
+		{
+			name: "motion after click",
+			frame: []input.Event{
+				{Type: input.EV_REL, Code: uint16(input.REL_X), Value: 3},
+				report,
+			},
+			exp: []input.Event{
+				{Type: input.EV_KEY, Code: uint16(input.BTN_MIDDLE), Value: 0},
+				{Type: input.EV_REL, Code: uint16(input.REL_X), Value: 3},
+				report,
+			},
+		},
+		{
+			name: "scroll press after motion",
+			frame: []input.Event{
+				{Type: input.EV_REL, Code: uint16(input.REL_Y), Value: -12},
+				{Type: input.EV_KEY, Code: uint16(input.BTN_MIDDLE), Value: 1},
+				report,
+			},
+			exp: []input.Event{
+				{Type: input.EV_REL, Code: uint16(input.REL_WHEEL_HI_RES), Value: 120},
+				{Type: input.EV_REL, Code: uint16(input.REL_WHEEL), Value: 1},
+				report,
+			},
+		},
+		{
+			name: "scroll",
+			frame: []input.Event{
+				{Type: input.EV_REL, Code: uint16(input.REL_X), Value: 3},
+				{Type: input.EV_REL, Code: uint16(input.REL_Y), Value: 12},
+				report,
+			},
+			exp: []input.Event{
+				{Type: input.EV_REL, Code: uint16(input.REL_WHEEL_HI_RES), Value: -120},
+				{Type: input.EV_REL, Code: uint16(input.REL_WHEEL), Value: -1},
+				{Type: input.EV_REL, Code: uint16(input.REL_HWHEEL_HI_RES), Value: 30},
+				report,
+			},
+		},
+		{
+			name:  "scroll release",
+			frame: []input.Event{{Type: input.EV_KEY, Code: uint16(input.BTN_MIDDLE), Value: 0}, report},
+			exp:   []input.Event{report},
+		},
+	}
+
+	for _, test = range tests {
+		got = scroll.Filter(test.frame)
+		if !slices.Equal(got, test.exp) {
+			t.Errorf("%s: got: %+v, exp: %+v", test.name, got, test.exp)
+		}
+	}
+}
+
+func TestButtonScrollChain(t *testing.T) {
+	var (
+		chain  pipeline.Chain
+		at     time.Time
+		stamp  input.EventTime
+		click  input.Event
+		report input.Event
+		got    []input.Event
+		exp    []input.Event
+		due    time.Time
+		ok     bool
+	)
+
+	t.Parallel()
+
+	chain = pipeline.Chain{
+		pointer.NewButtonScroll(pointer.ButtonScrollOptions{}),
+		&pointer.WheelFilter{Vertical: pointer.Wheel{Scale: 2}},
+	}
+	at = time.Unix(1000, 0)
+	stamp = input.NewEventTime(at)
+	click = input.Event{Time: stamp, Type: input.EV_KEY, Code: uint16(input.BTN_MIDDLE)}
+	report = input.Event{Time: stamp, Type: input.EV_SYN, Code: uint16(input.SYN_REPORT)}
+
+	got = chain.Filter([]input.Event{
+		{Time: stamp, Type: input.EV_REL, Code: uint16(input.REL_Y), Value: -12},
+		{Time: stamp, Type: input.EV_KEY, Code: uint16(input.BTN_MIDDLE), Value: 1},
+		report,
+	})
+	exp = []input.Event{
+		{Time: stamp, Type: input.EV_REL, Code: uint16(input.REL_WHEEL_HI_RES), Value: 240},
+		{Time: stamp, Type: input.EV_REL, Code: uint16(input.REL_WHEEL), Value: 2},
+		report,
+	}
+
+	if !slices.Equal(got, exp) {
+		t.Errorf("scroll: got: %+v, exp: %+v", got, exp)
+	}
+
+	for _, click.Value = range []int32{0, 1} {
+		got = chain.Filter([]input.Event{click, report})
+		if got != nil {
+			t.Errorf("button %d: got: %+v, exp: %v", click.Value, got, nil)
+		}
+	}
+
+	click.Value = 0
+	got = chain.Filter([]input.Event{click, report})
+	exp = []input.Event{{Time: click.Time, Type: input.EV_KEY, Code: click.Code, Value: 1}, report}
+
+	if !slices.Equal(got, exp) {
+		t.Errorf("click: got: %+v, exp: %+v", got, exp)
+	}
+
+	due, ok = chain.Pending()
+	if !ok || !due.Equal(at) {
+		t.Fatalf("pending: got: %v %v, exp: %v true", due, ok, at)
+	}
+
+	got = chain.Flush(at)
+	exp = []input.Event{click, report}
+
+	if !slices.Equal(got, exp) {
+		t.Errorf("flush: got: %+v, exp: %+v", got, exp)
+	}
+
+	_, ok = chain.Pending()
+	if ok {
+		t.Errorf("pending after flush: got: %v, exp: %v", ok, false)
+	}
+}
@@ -0,0 +1,146 @@
+package pointer
+
+import (
+	"errors"
+	"fmt"
+	"math"
+)
+
+// Profile maps pointer velocity to an acceleration factor. Velocity is
+// measured in device units per millisecond, normalised to a 1000 DPI
+// device, so the same profile feels alike on mice of different
+// resolutions. The returned factor multiplies the motion delta.
+type Profile interface {
+	Factor(velocity float64) float64
+}
+
+// ErrInvalidCurve is returned by [NewCurveProfile] when the curve points
+// are empty, unsorted or negative.
+var ErrInvalidCurve error = errors.New("invalid acceleration curve")
+
+// FlatProfile applies a constant factor regardless of velocity. Speed is
+// in the range [-1, 1] and maps to a factor of Speed+1, so zero leaves
+// motion unchanged. This matches libinput's flat profile.
+type FlatProfile struct {
+	// Speed is the pointer speed adjustment in the range [-1, 1].
+	Speed float64
+}
+
+// AdaptiveProfile increases the factor with velocity: slow, precise
+// motion is decelerated, and fast motion is accelerated up to a maximum.
+// It is modelled on libinput's adaptive profile for mice.
+type AdaptiveProfile struct {
+	// Speed is the pointer speed adjustment in the range [-1, 1]. Higher
+	// values lower the velocity at which acceleration starts and raise
+	// the maximum factor.
+	Speed float64
+}
+
+// CurvePoint is a single point of a [CurveProfile].
+type CurvePoint struct {
+	// Velocity is the pointer velocity in units per millisecond at
+	// 1000 DPI.
+	Velocity float64
+
+	// Factor is the acceleration factor applied at Velocity.
+	Factor float64
+}
+
+// CurveProfile applies a user-defined factor curve. Factors between
+// points are linearly interpolated; velocities outside the curve use the
+// factor of the nearest end point.
+type CurveProfile struct {
+	points []CurvePoint
+}
+
+const (
+	flatMinFactor         = 0.005
+	adaptiveThreshold     = 0.4
+	adaptiveMinThreshold  = 0.2
+	adaptiveThresholdStep = 0.25
+	adaptiveAccel         = 2.0
+	adaptiveAccelStep     = 1.5
+	adaptiveIncline       = 1.1
+)
+
+// Factor returns Speed+1, clamped so motion never stops entirely.
+func (profile FlatProfile) Factor(float64) float64 {
+	return math.Max(flatMinFactor, 1+clampSpeed(profile.Speed))
+}
+
+// Factor returns the acceleration factor for velocity.
+func (profile AdaptiveProfile) Factor(velocity float64) float64 {
+	var speed, threshold, maxAccel, slow, fast float64
+
+	speed = clampSpeed(profile.Speed)
+	threshold = math.Max(adaptiveMinThreshold, adaptiveThreshold-adaptiveThresholdStep*speed)
+	maxAccel = adaptiveAccel + adaptiveAccelStep*speed
+
+	slow = math.Min(1, 0.3+velocity*4)
+	fast = 1 + (velocity-threshold)*adaptiveIncline
+
+	if fast > 1 {
+		return math.Min(maxAccel, fast)
+	}
+
+	return math.Min(maxAccel, slow)
+}
+
+// NewCurveProfile returns a [CurveProfile] through points. Points must be
+// non-empty, sorted by strictly increasing Velocity, and have
+// non-negative velocities and factors; otherwise an error wrapping
+// [ErrInvalidCurve] is returned.
+func NewCurveProfile(points []CurvePoint) (*CurveProfile, error) {
+	var (
+		idx   int
+		point CurvePoint
+	)
+
+	if len(points) == 0 {
+		return nil, fmt.Errorf("no points: %w", ErrInvalidCurve)
+	}
+
+	for idx, point = range points {
+		if point.Velocity < 0 || point.Factor < 0 {
+			return nil, fmt.Errorf("point %d: negative value: %w", idx, ErrInvalidCurve)
+		}
+
+		if idx > 0 && point.Velocity <= points[idx-1].Velocity {
+			return nil, fmt.Errorf("point %d: velocity not increasing: %w", idx, ErrInvalidCurve)
+		}
+	}
+
+	return &CurveProfile{
+		points: append([]CurvePoint(nil), points...),
+	}, nil
+}
+
+// Factor returns the interpolated acceleration factor for velocity.
+func (profile *CurveProfile) Factor(velocity float64) float64 {
+	var (
+		idx        int
+		prev, next CurvePoint
+	)
+
+	if velocity <= profile.points[0].Velocity {
+		return profile.points[0].Factor
+	}
+
+	for idx = 1; idx < len(profile.points); idx++ {
+		next = profile.points[idx]
+		if velocity > next.Velocity {
+			continue
+		}
+
+		prev = profile.points[idx-1]
+
+		return prev.Factor + (next.Factor-prev.Factor)*
+			(velocity-prev.Velocity)/(next.Velocity-prev.Velocity)
+	}
+
+	return profile.points[len(profile.points)-1].Factor
+}
+
+func clampSpeed(speed float64) float64 {
+	return math.Max(-1, math.Min(1, speed))
+}
@@ -0,0 +1,253 @@
+package pointer
+
+import (
+	"slices"
+	"time"
+
+	"github.com/andrieee44/gopkg/linux/evdev"
+	"github.com/andrieee44/gopkg/linux/uapi/input"
+)
+
+// DefaultScrollSpeed is the number of high-resolution wheel units a
+// [ButtonScroll] scrolls per unit of pointer motion when
+// [ButtonScrollOptions].Speed is zero, so 12 units of motion scroll one
+// detent.
+const DefaultScrollSpeed = 10.0
+
+// ButtonScrollOptions configures a [ButtonScroll].
+type ButtonScrollOptions struct {
+	// Button is the button that turns motion into scrolling while held.
+	// It defaults to [input.BTN_MIDDLE]. It is matched after Remap.
+	Button input.KeyCode
+
+	// Speed is the number of high-resolution wheel units scrolled per
+	// unit of motion. It defaults to [DefaultScrollSpeed]; a negative
+	// speed reverses the scroll direction.
+	Speed float64
+
+	// Remap replaces buttons, such as [input.BTN_SIDE] with
+	// [input.BTN_EXTRA] and back to swap them.
+	Remap map[input.KeyCode]input.KeyCode
+}
+
+// ButtonScroll turns the motion of a relative pointer such as a trackball
+// or trackpoint into scrolling while a button is held, as done with the
+// middle button of trackpoints. Pointer motion of [input.REL_X] and
+// [input.REL_Y] becomes [input.REL_HWHEEL] and [input.REL_WHEEL] motion
+// with their high-resolution counterparts, scrolling in the direction of
+// the motion. The button itself is held back: a click without motion is
+// passed on when released, its press in the frame of the release and its
+// release held for the next frame, while a press used to scroll is
+// dropped. ButtonScroll is a Deferrer of the pipeline package, so a
+// pipeline Chain emits the held release at once and passes it through
+// the filters after it. The zero value is not usable; create one with
+// [NewButtonScroll].
+type ButtonScroll struct {
+	button               input.KeyCode
+	remap                map[input.KeyCode]input.KeyCode
+	vertical, horizontal Wheel
+	held, scrolled       bool
+
+	// pending is set when the release of a click is held back, due at
+	// due.
+	pending bool
+	due     time.Time
+}
+
+// NewButtonScroll returns a [ButtonScroll] configured by opts.
+func NewButtonScroll(opts ButtonScrollOptions) *ButtonScroll {
+	if opts.Button == 0 {
+		opts.Button = input.BTN_MIDDLE
+	}
+
+	if opts.Speed == 0 {
+		opts.Speed = DefaultScrollSpeed
+	}
+
+	return &ButtonScroll{
+		button:     opts.Button,
+		remap:      opts.Remap,
+		vertical:   Wheel{Scale: -opts.Speed},
+		horizontal: Wheel{Scale: opts.Speed},
+	}
+}
+
+// AddCapabilities adds the wheel axes and remapped buttons the
+// [ButtonScroll] emits to snap, the snapshot of its source, so a uinput
+// device made from it supports them.
+func (scroll *ButtonScroll) AddCapabilities(snap *evdev.Snapshot) {
+	var key input.KeyCode
+
+	snap.Relative = append(
+		snap.Relative,
+		input.REL_WHEEL,
+		input.REL_HWHEEL,
+		input.REL_WHEEL_HI_RES,
+		input.REL_HWHEEL_HI_RES,
+	)
+	slices.Sort(snap.Relative)
+	snap.Relative = slices.Compact(snap.Relative)
+
+	if snap.Key == nil {
+		snap.Key = make(map[input.KeyCode]bool)
+	}
+
+	for _, key = range scroll.remap {
+		snap.Key[key] = false
+	}
+
+	snap.Key[scroll.button] = false
+}
+
+// Filter remaps the buttons of frame, a batch of events ending with
+// [input.SYN_REPORT], and turns its pointer motion into scrolling while
+// the scroll button is held, including motion before the press of the
+// button within frame. A release held back by the last click is passed
+// on first, if [ButtonScroll.Flush] has not done so.
+func (scroll *ButtonScroll) Filter(frame []input.Event) []input.Event {
+	var (
+		out, syncEvents []input.Event
+		event           input.Event
+		at              time.Time
+		dx, dy          int32
+		idx, pressIdx   int
+		click           bool
+	)
+
+	out = make([]input.Event, 0, len(frame)+1)
+
+	if scroll.pending {
+		scroll.pending = false
+		out = append(out, buttonEvent(scroll.due, scroll.button, 0))
+	}
+
+	pressIdx = slices.IndexFunc(frame, scroll.isPress)
+	if pressIdx >= 0 {
+		scroll.press(1)
+	}
+
+	for idx, event = range frame {
+		at = event.Time.Time()
+
+		if event.Type == input.EV_KEY {
+			event.Code = uint16(scroll.target(event))
+		}
+
+		switch {
+		case idx == pressIdx:
+		case event.Type == input.EV_SYN:
+			syncEvents = append(syncEvents, event)
+		case event.Type == input.EV_KEY && input.KeyCode(event.Code) == scroll.button:
+			click = scroll.press(event.Value) || click
+		case event.Type == input.EV_REL && scroll.held &&
+			input.RelativeCode(event.Code) == input.REL_X:
+			dx += event.Value
+			scroll.scrolled = true
+		case event.Type == input.EV_REL && scroll.held &&
+			input.RelativeCode(event.Code) == input.REL_Y:
+			dy += event.Value
+			scroll.scrolled = true
+		default:
+			out = append(out, event)
+		}
+	}
+
+	if dx != 0 || dy != 0 {
+		out = (&wheelFrame{hiRes: dy, hasHiRes: true}).emit(
+			out,
+			&scroll.vertical,
+			at,
+			input.REL_WHEEL_HI_RES,
+			input.REL_WHEEL,
+		)
+		out = (&wheelFrame{hiRes: dx, hasHiRes: true}).emit(
+			out,
+			&scroll.horizontal,
+			at,
+			input.REL_HWHEEL_HI_RES,
+			input.REL_HWHEEL,
+		)
+	}
+
+	if click {
+		scroll.pending, scroll.due = true, at
+		out = append(out, buttonEvent(at, scroll.button, 1))
+	}
+
+	return append(out, syncEvents...)
+}
+
+// Pending returns the time the release of the last click is due, and
+// whether it is held back.
+func (scroll *ButtonScroll) Pending() (time.Time, bool) {
+	return scroll.due, scroll.pending
+}
+
+// Flush returns a frame with the release of the last click if it is due
+// at now, or nil otherwise.
+func (scroll *ButtonScroll) Flush(now time.Time) []input.Event {
+	if !scroll.pending || scroll.due.After(now) {
+		return nil
+	}
+
+	scroll.pending = false
+
+	return []input.Event{
+		buttonEvent(scroll.due, scroll.button, 0),
+		{
+			Time: input.NewEventTime(now),
+			Type: input.EV_SYN,
+			Code: uint16(input.SYN_REPORT),
+		},
+	}
+}
+
+// press records a change of the scroll button and reports whether it
+// completes a click without motion.
+func (scroll *ButtonScroll) press(value int32) bool {
+	switch value {
+	case 1:
+		scroll.held, scroll.scrolled = true, false
+		scroll.vertical.Reset()
+		scroll.horizontal.Reset()
+	case 0:
+		if !scroll.held {
+			return false
+		}
+
+		scroll.held = false
+
+		return !scroll.scrolled
+	}
+
+	return false
+}
+
+// isPress reports whether event presses the scroll button.
+func (scroll *ButtonScroll) isPress(event input.Event) bool {
+	return event.Type == input.EV_KEY && event.Value == 1 && scroll.target(event) == scroll.button
+}
+
+// target returns the button of the key event after remapping.
+func (scroll *ButtonScroll) target(event input.Event) input.KeyCode {
+	var (
+		target input.KeyCode
+		ok     bool
+	)
+
+	target, ok = scroll.remap[input.KeyCode(event.Code)]
+	if ok {
+		return target
+	}
+
+	return input.KeyCode(event.Code)
+}
+
+func buttonEvent(at time.Time, button input.KeyCode, value int32) input.Event {
+	return input.Event{
+		Time:  input.NewEventTime(at),
+		Type:  input.EV_KEY,
+		Code:  uint16(button),
+		Value: value,
+	}
+}
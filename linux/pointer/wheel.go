@@ -0,0 +1,160 @@
+package pointer
+
+import (
+	"math"
+	"time"
+
+	"github.com/andrieee44/gopkg/linux/uapi/input"
+)
+
+// HiResDetent is the number of high-resolution wheel units in one detent
+// of a legacy wheel, as defined by the kernel for [input.REL_WHEEL_HI_RES]
+// and [input.REL_HWHEEL_HI_RES].
+const HiResDetent = 120
+
+// Wheel accumulates scroll wheel motion in high-resolution units. It
+// scales the motion, keeps sub-unit remainders, and reports a legacy
+// detent each time the accumulated motion crosses [HiResDetent] units in
+// one direction. Changing direction discards the partial detent. The
+// zero value is ready to use.
+type Wheel struct {
+	// Scale multiplies the wheel motion. Zero is treated as 1.
+	Scale float64
+
+	rem     float64
+	partial int32
+}
+
+// WheelFilter applies a [Wheel] to the vertical and horizontal wheel
+// axes of an event stream. The zero value is ready to use.
+type WheelFilter struct {
+	// Vertical handles [input.REL_WHEEL] and [input.REL_WHEEL_HI_RES].
+	Vertical Wheel
+
+	// Horizontal handles [input.REL_HWHEEL] and [input.REL_HWHEEL_HI_RES].
+	Horizontal Wheel
+}
+
+// AddHiRes adds value high-resolution units of wheel motion and returns
+// the scaled high-resolution motion to emit along with the number of
+// whole legacy detents completed.
+func (wheel *Wheel) AddHiRes(value int32) (int32, int32) {
+	var (
+		scale, scaled    float64
+		hiRes, detents   int32
+		sameSign, moving bool
+	)
+
+	scale = wheel.Scale
+	if scale == 0 {
+		scale = 1
+	}
+
+	scaled = float64(value)*scale + wheel.rem
+	hiRes = int32(math.Trunc(scaled))
+	wheel.rem = scaled - float64(hiRes)
+
+	moving = hiRes != 0 && wheel.partial != 0
+	sameSign = (hiRes > 0) == (wheel.partial > 0)
+
+	if moving && !sameSign {
+		wheel.partial = 0
+	}
+
+	wheel.partial += hiRes
+	detents = wheel.partial / HiResDetent
+	wheel.partial -= detents * HiResDetent
+
+	return hiRes, detents
+}
+
+// AddDetents adds value legacy detents of wheel motion, for devices that
+// do not report high-resolution scrolling. It is equivalent to
+// [Wheel.AddHiRes] with value multiplied by [HiResDetent].
+func (wheel *Wheel) AddDetents(value int32) (int32, int32) {
+	return wheel.AddHiRes(value * HiResDetent)
+}
+
+// Reset discards any partial detent and sub-unit remainder.
+func (wheel *Wheel) Reset() {
+	wheel.rem = 0
+	wheel.partial = 0
+}
+
+// Filter rewrites the wheel events of frame, a batch of events ending
+// with [input.SYN_REPORT]. When a frame carries high-resolution motion
+// the legacy wheel events are recomputed from it; otherwise legacy
+// detents are converted to high-resolution units. Both the
+// high-resolution and legacy events are emitted when non-zero, so the
+// sink should enable both codes.
+func (filter *WheelFilter) Filter(frame []input.Event) []input.Event {
+	var (
+		out, syncEvents      []input.Event
+		event                input.Event
+		at                   time.Time
+		vertical, horizontal wheelFrame
+	)
+
+	out = make([]input.Event, 0, len(frame))
+
+	for _, event = range frame {
+		at = event.Time.Time()
+
+		switch {
+		case event.Type == input.EV_SYN:
+			syncEvents = append(syncEvents, event)
+		case event.Type != input.EV_REL:
+			out = append(out, event)
+		case !vertical.add(event, input.REL_WHEEL_HI_RES, input.REL_WHEEL) &&
+			!horizontal.add(event, input.REL_HWHEEL_HI_RES, input.REL_HWHEEL):
+			out = append(out, event)
+		}
+	}
+
+	out = vertical.emit(out, &filter.Vertical, at, input.REL_WHEEL_HI_RES, input.REL_WHEEL)
+	out = horizontal.emit(out, &filter.Horizontal, at, input.REL_HWHEEL_HI_RES, input.REL_HWHEEL)
+
+	return append(out, syncEvents...)
+}
+
+type wheelFrame struct {
+	hiRes, legacy       int32
+	hasHiRes, hasLegacy bool
+}
+
+func (frame *wheelFrame) add(event input.Event, hiRes, legacy input.RelativeCode) bool {
+	switch input.RelativeCode(event.Code) {
+	case hiRes:
+		frame.hiRes += event.Value
+		frame.hasHiRes = true
+	case legacy:
+		frame.legacy += event.Value
+		frame.hasLegacy = true
+	default:
+		return false
+	}
+
+	return true
+}
+
+func (frame *wheelFrame) emit(
+	events []input.Event,
+	wheel *Wheel,
+	at time.Time,
+	hiResCode, legacyCode input.RelativeCode,
+) []input.Event {
+	var hiRes, detents int32
+
+	switch {
+	case frame.hasHiRes:
+		hiRes, detents = wheel.AddHiRes(frame.hiRes)
+	case frame.hasLegacy:
+		hiRes, detents = wheel.AddDetents(frame.legacy)
+	default:
+		return events
+	}
+
+	events = appendRel(events, at, hiResCode, hiRes)
+
+	return appendRel(events, at, legacyCode, detents)
+}
@@ -0,0 +1,305 @@
+package tablet
+
+import (
+	"fmt"
+	"math"
+	"slices"
+	"time"
+
+	"github.com/andrieee44/gopkg/linux/axis"
+	"github.com/andrieee44/gopkg/linux/evdev"
+	"github.com/andrieee44/gopkg/linux/uapi/input"
+)
+
+// PadEventKind identifies the control a [PadEvent] reports.
+type PadEventKind uint8
+
+const (
+	// PadButton reports an express key.
+	PadButton PadEventKind = iota
+
+	// PadRing reports a touch ring.
+	PadRing
+
+	// PadStrip reports a touch strip.
+	PadStrip
+)
+
+// PadEvent reports a change of a pad control.
+type PadEvent struct {
+	// Time is the time of the input frame.
+	Time time.Time
+
+	// Kind is the kind of control.
+	Kind PadEventKind
+
+	// Index is the ring or strip number, starting at zero.
+	Index int
+
+	// Button is the key code of a button.
+	Button input.KeyCode
+
+	// Pressed is the new state of a button.
+	Pressed bool
+
+	// Value is the finger position on a ring, as a fraction of a turn
+	// clockwise from the top in [0, 1), or on a strip in [0, 1] from the
+	// top or left. It is -1 when the finger is lifted.
+	Value float64
+}
+
+// Pad interprets the events of a tablet pad: the express keys, touch
+// rings and touch strips that tablets report on a device separate from
+// their tools. It is not safe for concurrent use.
+type Pad struct {
+	dev     *evdev.Device
+	buttons []input.KeyCode
+	pressed map[input.KeyCode]bool
+	axes    map[input.AbsoluteCode]*axis.Axis
+	events  []PadEvent
+}
+
+var ringCodes []input.AbsoluteCode = []input.AbsoluteCode{input.ABS_WHEEL, input.ABS_THROTTLE}
+
+var stripCodes []input.AbsoluteCode = []input.AbsoluteCode{input.ABS_RX, input.ABS_RY}
+
+// IsPad reports whether snap describes a tablet pad: a device without
+// tablet tools that reports numbered buttons, or a touch ring or strip
+// alongside the pad marker [input.BTN_STYLUS].
+func IsPad(snap *evdev.Snapshot) bool {
+	var (
+		abs   input.AbsoluteCode
+		found bool
+	)
+
+	if IsTablet(snap) {
+		return false
+	}
+
+	_, found = snap.Key[input.BTN_0]
+	if found {
+		return true
+	}
+
+	_, found = snap.Key[input.BTN_STYLUS]
+	if !found {
+		return false
+	}
+
+	for _, abs = range slices.Concat(ringCodes, stripCodes) {
+		_, found = snap.Absolute[abs]
+		if found {
+			return true
+		}
+	}
+
+	return false
+}
+
+// OpenPad returns a [Pad] reading from dev.
+func OpenPad(dev *evdev.Device) (*Pad, error) {
+	var (
+		snap *evdev.Snapshot
+		pad  *Pad
+		err  error
+	)
+
+	snap, err = dev.Snapshot()
+	if err != nil {
+		return nil, fmt.Errorf("failed to open tablet pad: %w", err)
+	}
+
+	if !IsPad(snap) {
+		return nil, fmt.Errorf("%s: %w", dev.Filename(), ErrNotPad)
+	}
+
+	pad = NewPad(snap)
+	pad.dev = dev
+
+	return pad, nil
+}
+
+// NewPad returns a [Pad] for a device with the capabilities and initial
+// state in snap.
+func NewPad(snap *evdev.Snapshot) *Pad {
+	var (
+		pad     *Pad
+		code    input.KeyCode
+		pressed bool
+		abs     input.AbsoluteCode
+		info    input.AbsInfo
+		found   bool
+	)
+
+	pad = &Pad{
+		pressed: make(map[input.KeyCode]bool),
+		axes:    make(map[input.AbsoluteCode]*axis.Axis),
+	}
+
+	for code, pressed = range snap.Key {
+		if !isPadButton(code) {
+			continue
+		}
+
+		pad.buttons = append(pad.buttons, code)
+		pad.pressed[code] = pressed
+	}
+
+	slices.Sort(pad.buttons)
+
+	for _, abs = range slices.Concat(ringCodes, stripCodes) {
+		info, found = snap.Absolute[abs]
+		if found {
+			pad.axes[abs] = axis.NewAxis(info, axis.Unipolar)
+		}
+	}
+
+	return pad
+}
+
+// Buttons returns the key codes of the pad's buttons in ascending order.
+func (pad *Pad) Buttons() []input.KeyCode {
+	return slices.Clone(pad.buttons)
+}
+
+// Rings returns the number of touch rings.
+func (pad *Pad) Rings() int {
+	return pad.count(ringCodes)
+}
+
+// Strips returns the number of touch strips.
+func (pad *Pad) Strips() int {
+	return pad.count(stripCodes)
+}
+
+// Pressed reports whether button is held down.
+func (pad *Pad) Pressed(button input.KeyCode) bool {
+	return pad.pressed[button]
+}
+
+// Update processes a raw event from the pad. Changes are collected until
+// the [input.SYN_REPORT] that ends the frame, which returns them.
+func (pad *Pad) Update(event input.Event) []PadEvent {
+	var (
+		code   input.KeyCode
+		abs    input.AbsoluteCode
+		idx    int
+		events []PadEvent
+		found  bool
+	)
+
+	switch event.Type {
+	case input.EV_KEY:
+		code = input.KeyCode(event.Code)
+
+		_, found = pad.pressed[code]
+		if !found || pad.pressed[code] == (event.Value != 0) {
+			return nil
+		}
+
+		pad.pressed[code] = event.Value != 0
+		pad.events = append(pad.events, PadEvent{
+			Kind:    PadButton,
+			Button:  code,
+			Pressed: event.Value != 0,
+		})
+	case input.EV_ABS:
+		abs = input.AbsoluteCode(event.Code)
+
+		idx = slices.Index(ringCodes, abs)
+		if idx >= 0 && pad.axes[abs] != nil {
+			pad.events = append(pad.events, PadEvent{
+				Kind:  PadRing,
+				Index: idx,
+				Value: pad.ring(abs, event.Value),
+			})
+
+			return nil
+		}
+
+		idx = slices.Index(stripCodes, abs)
+		if idx >= 0 && pad.axes[abs] != nil {
+			pad.events = append(pad.events, PadEvent{
+				Kind:  PadStrip,
+				Index: idx,
+				Value: pad.strip(abs, event.Value),
+			})
+		}
+	case input.EV_SYN:
+		if input.SyncCode(event.Code) != input.SYN_REPORT {
+			return nil
+		}
+
+		events = pad.events
+		pad.events = nil
+
+		for idx = range events {
+			events[idx].Time = event.Time.Time()
+		}
+
+		return events
+	}
+
+	return nil
+}
+
+// ReadEvents reads the pad and returns channels delivering its events
+// and read errors. Both channels are closed when the device stops
+// delivering events. It reports [ErrNoDevice] for a [Pad] created with
+// [NewPad].
+func (pad *Pad) ReadEvents() (<-chan PadEvent, <-chan error) {
+	return read(pad.dev, pad.Update)
+}
+
+func (pad *Pad) count(codes []input.AbsoluteCode) int {
+	var (
+		abs   input.AbsoluteCode
+		count int
+	)
+
+	for _, abs = range codes {
+		if pad.axes[abs] != nil {
+			count++
+		}
+	}
+
+	return count
+}
+
+// ring converts a ring position, which tablets report as 0 once the
+// finger is lifted, to a fraction of a turn.
+func (pad *Pad) ring(abs input.AbsoluteCode, value int32) float64 {
+	var info input.AbsInfo
+
+	info = pad.axes[abs].Info
+	if value == 0 || info.Maximum <= info.Minimum {
+		return -1
+	}
+
+	return float64(value-info.Minimum) / float64(info.Maximum-info.Minimum+1)
+}
+
+// strip converts a strip position, which Wacom tablets report as a single
+// set bit and 0 once the finger is lifted, to a fraction of its length.
+func (pad *Pad) strip(abs input.AbsoluteCode, value int32) float64 {
+	var info input.AbsInfo
+
+	info = pad.axes[abs].Info
+	if value <= 0 || info.Maximum <= 1 {
+		return -1
+	}
+
+	return math.Min(1, math.Log2(float64(value))/math.Log2(float64(info.Maximum)))
+}
+
+func isPadButton(code input.KeyCode) bool {
+	var found bool
+
+	if code == input.BTN_STYLUS || code == input.BTN_STYLUS2 || code == input.BTN_TOUCH {
+		return false
+	}
+
+	_, found = toolCodes[code]
+
+	return !found && code != input.BTN_TOOL_FINGER
+}
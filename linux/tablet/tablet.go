@@ -0,0 +1,445 @@
+// Package tablet interprets the events of graphics tablets. A [Tablet]
+// tracks the tools on a tablet's drawing surface, such as pens, erasers
+// and tablet mice, and reports their proximity, position, pressure,
+// tilt, rotation, distance and buttons as [ToolState] records. A [Pad]
+// handles the separate device tablets create for their express keys,
+// touch rings and touch strips.
+//
+// The kernel reports tools with the BTN_TOOL_* codes described in
+// Documentation/input/event-codes.rst: a tool enters proximity when its
+// code is set and leaves when it is cleared, and tablets that identify
+// individual tools report a serial number with [input.MSC_SERIAL].
+package tablet
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"slices"
+	"time"
+
+	"github.com/andrieee44/gopkg/linux/axis"
+	"github.com/andrieee44/gopkg/linux/evdev"
+	"github.com/andrieee44/gopkg/linux/uapi/input"
+)
+
+// DefaultTiltRange is the tilt, in degrees, assumed for the extremes of
+// a tilt axis that does not report its resolution.
+const DefaultTiltRange float64 = 64
+
+var (
+	// ErrNotTablet is returned by [Open] for a device that reports no
+	// tablet tools.
+	ErrNotTablet error = errors.New("device is not a tablet")
+
+	// ErrNotPad is returned by [OpenPad] for a device that is not a
+	// tablet pad.
+	ErrNotPad error = errors.New("device is not a tablet pad")
+
+	// ErrNoDevice is reported when reading from a [Tablet] or [Pad]
+	// created from a snapshot without a device.
+	ErrNoDevice error = errors.New("tablet has no device")
+)
+
+// Tablet tracks the tools of a tablet's drawing surface. It is not safe
+// for concurrent use.
+type Tablet struct {
+	dev      *evdev.Device
+	axes     map[input.AbsoluteCode]*axis.Axis
+	raw      map[input.AbsoluteCode]int32
+	tool     Tool
+	touching bool
+	buttons  Buttons
+	changes  Changes
+	leaving  bool
+	tools    map[Tool]ToolState
+}
+
+// IsTablet reports whether snap describes a tablet with at least one
+// tool, as opposed to a pad or touchscreen.
+func IsTablet(snap *evdev.Snapshot) bool {
+	var (
+		code  input.KeyCode
+		found bool
+	)
+
+	_, found = snap.Absolute[input.ABS_X]
+	if !found {
+		return false
+	}
+
+	for code = range toolCodes {
+		_, found = snap.Key[code]
+		if found {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Open returns a [Tablet] reading from dev.
+func Open(dev *evdev.Device) (*Tablet, error) {
+	var (
+		snap *evdev.Snapshot
+		tab  *Tablet
+		err  error
+	)
+
+	snap, err = dev.Snapshot()
+	if err != nil {
+		return nil, fmt.Errorf("failed to open tablet: %w", err)
+	}
+
+	if !IsTablet(snap) {
+		return nil, fmt.Errorf("%s: %w", dev.Filename(), ErrNotTablet)
+	}
+
+	tab = NewTablet(snap)
+	tab.dev = dev
+
+	return tab, nil
+}
+
+// NewTablet returns a [Tablet] for a device with the capabilities and
+// initial state in snap. A tool already in proximity is picked up from
+// the snapshot's key state.
+func NewTablet(snap *evdev.Snapshot) *Tablet {
+	var (
+		tab      *Tablet
+		abs      input.AbsoluteCode
+		info     input.AbsInfo
+		code     input.KeyCode
+		toolType ToolType
+		rng      axis.Range
+	)
+
+	tab = &Tablet{
+		axes:  make(map[input.AbsoluteCode]*axis.Axis),
+		raw:   make(map[input.AbsoluteCode]int32),
+		tools: make(map[Tool]ToolState),
+	}
+
+	for abs, info = range snap.Absolute {
+		rng = axis.Unipolar
+		if abs == input.ABS_TILT_X || abs == input.ABS_TILT_Y {
+			rng = axis.Bipolar
+		}
+
+		tab.axes[abs] = axis.NewAxis(info, rng)
+		tab.raw[abs] = info.Value
+	}
+
+	for code, toolType = range toolCodes {
+		if snap.Key[code] {
+			tab.tool.Type = toolType
+			tab.changes |= ChangeProximity
+		}
+	}
+
+	tab.touching = snap.Key[input.BTN_TOUCH]
+	tab.tool.ID = tab.raw[input.ABS_MISC]
+
+	for code = range buttonCodes {
+		if snap.Key[code] {
+			tab.buttons |= buttonCodes[code]
+		}
+	}
+
+	return tab
+}
+
+// Axis returns the axis processor for abs, for converting raw values to
+// physical units. It reports false if the tablet lacks the axis.
+func (tab *Tablet) Axis(abs input.AbsoluteCode) (*axis.Axis, bool) {
+	var (
+		ax    *axis.Axis
+		found bool
+	)
+
+	ax, found = tab.axes[abs]
+
+	return ax, found
+}
+
+// Tools returns every tool seen since the [Tablet] was created.
+func (tab *Tablet) Tools() []Tool {
+	var (
+		tools []Tool
+		tool  Tool
+	)
+
+	for tool = range tab.tools {
+		tools = append(tools, tool)
+	}
+
+	slices.SortFunc(tools, func(a, b Tool) int {
+		if a.Type != b.Type {
+			return int(a.Type) - int(b.Type)
+		}
+
+		if a.Serial < b.Serial {
+			return -1
+		}
+
+		if a.Serial > b.Serial {
+			return 1
+		}
+
+		return int(a.ID) - int(b.ID)
+	})
+
+	return tools
+}
+
+// LastState returns the most recent state of tool. It reports false if
+// the tool has not been seen.
+func (tab *Tablet) LastState(tool Tool) (ToolState, bool) {
+	var (
+		state ToolState
+		found bool
+	)
+
+	state, found = tab.tools[tool]
+
+	return state, found
+}
+
+// Update processes a raw event from the tablet. Changes are collected
+// until the [input.SYN_REPORT] that ends the frame, which returns the
+// new state of the tool in proximity, if any changed.
+func (tab *Tablet) Update(event input.Event) []ToolState {
+	var (
+		code     input.KeyCode
+		toolType ToolType
+		button   Buttons
+		found    bool
+	)
+
+	switch event.Type {
+	case input.EV_KEY:
+		code = input.KeyCode(event.Code)
+
+		toolType, found = toolCodes[code]
+		if found {
+			tab.setTool(toolType, event.Value != 0)
+
+			return nil
+		}
+
+		if code == input.BTN_TOUCH {
+			tab.touching = event.Value != 0
+			tab.changes |= ChangeTip
+
+			return nil
+		}
+
+		button, found = buttonCodes[code]
+		if found {
+			tab.buttons &^= button
+			if event.Value != 0 {
+				tab.buttons |= button
+			}
+
+			tab.changes |= ChangeButtons
+		}
+	case input.EV_ABS:
+		tab.setAbs(input.AbsoluteCode(event.Code), event.Value)
+	case input.EV_MSC:
+		if input.MiscCode(event.Code) == input.MSC_SERIAL && event.Value != 0 {
+			tab.tool.Serial = uint32(event.Value)
+		}
+	case input.EV_SYN:
+		if input.SyncCode(event.Code) == input.SYN_REPORT {
+			return tab.frame(event.Time.Time())
+		}
+	}
+
+	return nil
+}
+
+// ReadStates reads the tablet and returns channels delivering tool
+// states and read errors. Both channels are closed when the device stops
+// delivering events. It reports [ErrNoDevice] for a [Tablet] created with
+// [NewTablet].
+func (tab *Tablet) ReadStates() (<-chan ToolState, <-chan error) {
+	return read(tab.dev, tab.Update)
+}
+
+func (tab *Tablet) setTool(toolType ToolType, inProximity bool) {
+	if inProximity {
+		tab.tool.Type = toolType
+		tab.leaving = false
+		tab.changes |= ChangeProximity
+
+		return
+	}
+
+	if toolType == tab.tool.Type {
+		tab.leaving = true
+		tab.changes |= ChangeProximity
+	}
+}
+
+func (tab *Tablet) setAbs(abs input.AbsoluteCode, value int32) {
+	var found bool
+
+	_, found = tab.axes[abs]
+	if !found {
+		return
+	}
+
+	if abs == input.ABS_MISC {
+		if value != 0 {
+			tab.tool.ID = value
+		}
+
+		return
+	}
+
+	tab.raw[abs] = value
+	tab.changes |= ChangeAxes
+}
+
+func (tab *Tablet) frame(at time.Time) []ToolState {
+	var state ToolState
+
+	if tab.tool.Type == ToolNone || tab.changes == 0 {
+		tab.changes = 0
+
+		return nil
+	}
+
+	state = ToolState{
+		Time:        at,
+		Tool:        tab.tool,
+		Changes:     tab.changes,
+		InProximity: !tab.leaving,
+		Touching:    tab.touching,
+		X:           tab.scale(input.ABS_X),
+		Y:           tab.scale(input.ABS_Y),
+		Pressure:    tab.scale(input.ABS_PRESSURE),
+		Distance:    tab.scale(input.ABS_DISTANCE),
+		TiltX:       tab.tilt(input.ABS_TILT_X),
+		TiltY:       tab.tilt(input.ABS_TILT_Y),
+		Rotation:    math.Mod(tab.scale(input.ABS_Z)*360, 360),
+		Slider:      tab.scale(input.ABS_WHEEL),
+		Buttons:     tab.buttons,
+	}
+
+	tab.tools[tab.tool] = state
+	tab.changes = 0
+
+	if tab.leaving {
+		tab.tool = Tool{}
+		tab.leaving = false
+	}
+
+	return []ToolState{state}
+}
+
+func (tab *Tablet) scale(abs input.AbsoluteCode) float64 {
+	var (
+		ax    *axis.Axis
+		found bool
+	)
+
+	ax, found = tab.axes[abs]
+	if !found {
+		return 0
+	}
+
+	return ax.Scale(tab.raw[abs])
+}
+
+func (tab *Tablet) tilt(abs input.AbsoluteCode) float64 {
+	var (
+		ax      *axis.Axis
+		radians float64
+		found   bool
+		err     error
+	)
+
+	ax, found = tab.axes[abs]
+	if !found {
+		return 0
+	}
+
+	radians, err = ax.Radians(tab.raw[abs])
+	if err != nil {
+		return ax.Scale(tab.raw[abs]) * DefaultTiltRange
+	}
+
+	return radians * 180 / math.Pi
+}
+
+// read starts a goroutine feeding the events of dev through update and
+// returns the channels it delivers the results and errors on.
+func read[T any](dev *evdev.Device, update func(input.Event) []T) (<-chan T, <-chan error) {
+	var (
+		outChan   chan T
+		errChan   chan error
+		rawEvents <-chan input.Event
+		rawErrs   <-chan error
+	)
+
+	outChan = make(chan T)
+	errChan = make(chan error, 1)
+
+	if dev == nil {
+		errChan <- ErrNoDevice
+
+		close(outChan)
+		close(errChan)
+
+		return outChan, errChan
+	}
+
+	rawEvents, rawErrs = dev.ReadEvents()
+
+	go serve(dev, rawEvents, rawErrs, update, outChan, errChan)
+
+	return outChan, errChan
+}
+
+func serve[T any](
+	dev *evdev.Device,
+	rawEvents <-chan input.Event,
+	rawErrs <-chan error,
+	update func(input.Event) []T,
+	outChan chan<- T,
+	errChan chan<- error,
+) {
+	var (
+		raw   input.Event
+		value T
+		ok    bool
+		err   error
+	)
+
+	for rawEvents != nil || rawErrs != nil {
+		select {
+		case raw, ok = <-rawEvents:
+			if !ok {
+				rawEvents = nil
+
+				continue
+			}
+
+			for _, value = range update(raw) {
+				outChan <- value
+			}
+		case err, ok = <-rawErrs:
+			if !ok {
+				rawErrs = nil
+
+				continue
+			}
+
+			errChan <- fmt.Errorf("%s: failed to read tablet: %w", dev.Filename(), err)
+		}
+	}
+
+	close(outChan)
+	close(errChan)
+}
@@ -0,0 +1,191 @@
+package tablet_test
+
+import (
+	"math"
+	"testing"
+
+	"github.com/andrieee44/gopkg/linux/evdev"
+	"github.com/andrieee44/gopkg/linux/tablet"
+	"github.com/andrieee44/gopkg/linux/uapi/input"
+)
+
+func penSnapshot() *evdev.Snapshot {
+	return &evdev.Snapshot{
+		Key: map[input.KeyCode]bool{
+			input.BTN_TOOL_PEN:    false,
+			input.BTN_TOOL_RUBBER: false,
+			input.BTN_TOUCH:       false,
+			input.BTN_STYLUS:      false,
+			input.BTN_STYLUS2:     false,
+		},
+		Absolute: map[input.AbsoluteCode]input.AbsInfo{
+			input.ABS_X:        {Maximum: 1000},
+			input.ABS_Y:        {Maximum: 500},
+			input.ABS_PRESSURE: {Maximum: 2047},
+			input.ABS_DISTANCE: {Maximum: 63},
+			input.ABS_TILT_X:   {Minimum: -64, Maximum: 63, Resolution: 57},
+			input.ABS_TILT_Y:   {Minimum: -64, Maximum: 63},
+			input.ABS_MISC:     {Maximum: math.MaxInt32},
+		},
+	}
+}
+
+func feed(tab *tablet.Tablet, events []input.Event) []tablet.ToolState {
+	var (
+		event  input.Event
+		states []tablet.ToolState
+	)
+
+	for _, event = range events {
+		states = append(states, tab.Update(event)...)
+	}
+
+	return states
+}
+
+func TestTabletProximity(t *testing.T) {
+	var (
+		tab    *tablet.Tablet
+		states []tablet.ToolState
+		state  tablet.ToolState
+		found  bool
+	)
+
+	t.Parallel()
+
+	tab = tablet.NewTablet(penSnapshot())
+
+	states = feed(tab, []input.Event{
+		{Type: input.EV_ABS, Code: uint16(input.ABS_X), Value: 500},
+		{Type: input.EV_SYN, Code: uint16(input.SYN_REPORT)},
+	})
+	if len(states) != 0 {
+		t.Errorf("without tool: got: %+v, exp: none", states)
+	}
+
+	states = feed(tab, []input.Event{
+		{Type: input.EV_KEY, Code: uint16(input.BTN_TOOL_PEN), Value: 1},
+		{Type: input.EV_ABS, Code: uint16(input.ABS_Y), Value: 250},
+		{Type: input.EV_ABS, Code: uint16(input.ABS_MISC), Value: 0x802},
+		{Type: input.EV_MSC, Code: uint16(input.MSC_SERIAL), Value: 0x1234},
+		{Type: input.EV_SYN, Code: uint16(input.SYN_REPORT)},
+	})
+	if len(states) != 1 {
+		t.Fatalf("proximity in: got: %+v, exp: one state", states)
+	}
+
+	state = states[0]
+	if !state.InProximity ||
+		state.Tool != (tablet.Tool{Type: tablet.ToolPen, Serial: 0x1234, ID: 0x802}) ||
+		state.Changes&tablet.ChangeProximity == 0 ||
+		state.X != 0.5 || state.Y != 0.5 {
+		t.Errorf("proximity in: got: %+v", state)
+	}
+
+	states = feed(tab, []input.Event{
+		{Type: input.EV_KEY, Code: uint16(input.BTN_TOUCH), Value: 1},
+		{Type: input.EV_KEY, Code: uint16(input.BTN_STYLUS), Value: 1},
+		{Type: input.EV_ABS, Code: uint16(input.ABS_PRESSURE), Value: 2047},
+		{Type: input.EV_ABS, Code: uint16(input.ABS_TILT_X), Value: 57},
+		{Type: input.EV_ABS, Code: uint16(input.ABS_TILT_Y), Value: -64},
+		{Type: input.EV_SYN, Code: uint16(input.SYN_REPORT)},
+	})
+	if len(states) != 1 {
+		t.Fatalf("touch: got: %+v, exp: one state", states)
+	}
+
+	state = states[0]
+	if !state.Touching ||
+		state.Pressure != 1 ||
+		!state.Buttons.Has(tablet.ButtonStylus) ||
+		math.Abs(state.TiltX-180/math.Pi) > 1e-9 ||
+		state.TiltY != -tablet.DefaultTiltRange {
+		t.Errorf("touch: got: %+v", state)
+	}
+
+	states = feed(tab, []input.Event{
+		{Type: input.EV_KEY, Code: uint16(input.BTN_TOUCH), Value: 0},
+		{Type: input.EV_KEY, Code: uint16(input.BTN_TOOL_PEN), Value: 0},
+		{Type: input.EV_ABS, Code: uint16(input.ABS_MISC), Value: 0},
+		{Type: input.EV_SYN, Code: uint16(input.SYN_REPORT)},
+	})
+	if len(states) != 1 || states[0].InProximity || states[0].Tool.Serial != 0x1234 {
+		t.Errorf("proximity out: got: %+v", states)
+	}
+
+	state, found = tab.LastState(tablet.Tool{Type: tablet.ToolPen, Serial: 0x1234, ID: 0x802})
+	if !found || state.InProximity {
+		t.Errorf("last state: got: (%+v, %t)", state, found)
+	}
+
+	if len(tab.Tools()) != 1 {
+		t.Errorf("tools: got: %v, exp: one tool", tab.Tools())
+	}
+}
+
+func TestIsPad(t *testing.T) {
+	var snap *evdev.Snapshot
+
+	t.Parallel()
+
+	if !tablet.IsTablet(penSnapshot()) || tablet.IsPad(penSnapshot()) {
+		t.Errorf("pen: got: tablet %t, pad %t", tablet.IsTablet(penSnapshot()), tablet.IsPad(penSnapshot()))
+	}
+
+	snap = &evdev.Snapshot{
+		Key: map[input.KeyCode]bool{input.BTN_0: false, input.BTN_1: false, input.BTN_STYLUS: false},
+		Absolute: map[input.AbsoluteCode]input.AbsInfo{
+			input.ABS_X:     {Maximum: 1},
+			input.ABS_WHEEL: {Maximum: 71},
+		},
+	}
+
+	if tablet.IsTablet(snap) || !tablet.IsPad(snap) {
+		t.Errorf("pad: got: tablet %t, pad %t", tablet.IsTablet(snap), tablet.IsPad(snap))
+	}
+}
+
+func TestPad(t *testing.T) {
+	var (
+		pad    *tablet.Pad
+		events []tablet.PadEvent
+		event  input.Event
+	)
+
+	t.Parallel()
+
+	pad = tablet.NewPad(&evdev.Snapshot{
+		Key: map[input.KeyCode]bool{input.BTN_0: false, input.BTN_1: false, input.BTN_STYLUS: false},
+		Absolute: map[input.AbsoluteCode]input.AbsInfo{
+			input.ABS_WHEEL: {Maximum: 71},
+			input.ABS_RX:    {Maximum: 4096},
+		},
+	})
+
+	if len(pad.Buttons()) != 2 || pad.Rings() != 1 || pad.Strips() != 1 {
+		t.Errorf("capabilities: got: %v, %d rings, %d strips", pad.Buttons(), pad.Rings(), pad.Strips())
+	}
+
+	for _, event = range []input.Event{
+		{Type: input.EV_KEY, Code: uint16(input.BTN_1), Value: 1},
+		{Type: input.EV_ABS, Code: uint16(input.ABS_WHEEL), Value: 18},
+		{Type: input.EV_ABS, Code: uint16(input.ABS_RX), Value: 64},
+		{Type: input.EV_SYN, Code: uint16(input.SYN_REPORT)},
+	} {
+		events = append(events, pad.Update(event)...)
+	}
+
+	if len(events) != 3 ||
+		events[0].Button != input.BTN_1 || !events[0].Pressed ||
+		events[1].Kind != tablet.PadRing || events[1].Value != 0.25 ||
+		events[2].Kind != tablet.PadStrip || events[2].Value != 0.5 {
+		t.Errorf("got: %+v", events)
+	}
+
+	events = pad.Update(input.Event{Type: input.EV_ABS, Code: uint16(input.ABS_WHEEL), Value: 0})
+	events = append(events, pad.Update(input.Event{Type: input.EV_SYN, Code: uint16(input.SYN_REPORT)})...)
+
+	if len(events) != 1 || events[0].Value != -1 {
+		t.Errorf("release: got: %+v", events)
+	}
+}
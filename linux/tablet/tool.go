@@ -0,0 +1,233 @@
+package tablet
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/andrieee44/gopkg/linux/uapi/input"
+)
+
+// ToolType is the kind of tool reported by a tablet.
+type ToolType uint8
+
+const (
+	// ToolNone means no tool is in proximity.
+	ToolNone ToolType = iota
+
+	// ToolPen is the tip of a pen, reported as [input.BTN_TOOL_PEN].
+	ToolPen
+
+	// ToolEraser is the eraser end of a pen, reported as
+	// [input.BTN_TOOL_RUBBER].
+	ToolEraser
+
+	// ToolBrush is a brush, reported as [input.BTN_TOOL_BRUSH].
+	ToolBrush
+
+	// ToolPencil is a pencil, reported as [input.BTN_TOOL_PENCIL].
+	ToolPencil
+
+	// ToolAirbrush is an airbrush, reported as
+	// [input.BTN_TOOL_AIRBRUSH].
+	ToolAirbrush
+
+	// ToolMouse is a tablet mouse, reported as [input.BTN_TOOL_MOUSE].
+	ToolMouse
+
+	// ToolLens is a lens cursor, reported as [input.BTN_TOOL_LENS].
+	ToolLens
+)
+
+// Buttons is a set of tool buttons.
+type Buttons uint8
+
+const (
+	// ButtonStylus is the first barrel button, [input.BTN_STYLUS].
+	ButtonStylus Buttons = 1 << iota
+
+	// ButtonStylus2 is the second barrel button, [input.BTN_STYLUS2].
+	ButtonStylus2
+
+	// ButtonStylus3 is the third barrel button, [input.BTN_STYLUS3].
+	ButtonStylus3
+
+	// ButtonLeft is the left button of a mouse or lens tool.
+	ButtonLeft
+
+	// ButtonRight is the right button of a mouse or lens tool.
+	ButtonRight
+
+	// ButtonMiddle is the middle button of a mouse or lens tool.
+	ButtonMiddle
+
+	// ButtonSide is the side button of a mouse or lens tool.
+	ButtonSide
+
+	// ButtonExtra is the extra button of a mouse or lens tool.
+	ButtonExtra
+)
+
+// Changes is a set of flags describing what changed in a [ToolState].
+type Changes uint8
+
+const (
+	// ChangeProximity means the tool entered or left proximity.
+	ChangeProximity Changes = 1 << iota
+
+	// ChangeTip means the tip touched or left the surface.
+	ChangeTip
+
+	// ChangeAxes means one or more axis values changed.
+	ChangeAxes
+
+	// ChangeButtons means one or more buttons changed.
+	ChangeButtons
+)
+
+// Tool identifies a physical tool. Tablets that report serial numbers
+// with [input.MSC_SERIAL] distinguish two pens of the same type; others
+// report a zero Serial.
+type Tool struct {
+	// Type is the kind of tool.
+	Type ToolType
+
+	// Serial is the tool's serial number, or zero if not reported.
+	Serial uint32
+
+	// ID is the hardware tool identifier reported with [input.ABS_MISC],
+	// or zero if not reported.
+	ID int32
+}
+
+// ToolState is the state of a tool at the end of an input frame. Axis
+// values the tablet does not report are zero.
+type ToolState struct {
+	// Time is the time of the input frame.
+	Time time.Time
+
+	// Tool is the tool the state belongs to.
+	Tool Tool
+
+	// Changes describes what changed since the previous state of the
+	// tool.
+	Changes Changes
+
+	// InProximity reports whether the tool is in proximity. The last
+	// state of a tool, reported as it leaves, has InProximity false.
+	InProximity bool
+
+	// Touching reports whether the tip touches the surface.
+	Touching bool
+
+	// X is the horizontal position in [0, 1] of the tablet's width.
+	X float64
+
+	// Y is the vertical position in [0, 1] of the tablet's height.
+	Y float64
+
+	// Pressure is the tip pressure in [0, 1].
+	Pressure float64
+
+	// Distance is the distance from the surface in [0, 1].
+	Distance float64
+
+	// TiltX is the tilt towards the positive X axis, in degrees.
+	TiltX float64
+
+	// TiltY is the tilt towards the positive Y axis, in degrees.
+	TiltY float64
+
+	// Rotation is the rotation of the tool about its own axis, in
+	// degrees in [0, 360).
+	Rotation float64
+
+	// Slider is the position of an airbrush finger wheel in [0, 1].
+	Slider float64
+
+	// Buttons is the set of pressed buttons.
+	Buttons Buttons
+}
+
+var toolCodes map[input.KeyCode]ToolType = map[input.KeyCode]ToolType{
+	input.BTN_TOOL_PEN:      ToolPen,
+	input.BTN_TOOL_RUBBER:   ToolEraser,
+	input.BTN_TOOL_BRUSH:    ToolBrush,
+	input.BTN_TOOL_PENCIL:   ToolPencil,
+	input.BTN_TOOL_AIRBRUSH: ToolAirbrush,
+	input.BTN_TOOL_MOUSE:    ToolMouse,
+	input.BTN_TOOL_LENS:     ToolLens,
+}
+
+var buttonCodes map[input.KeyCode]Buttons = map[input.KeyCode]Buttons{
+	input.BTN_STYLUS:  ButtonStylus,
+	input.BTN_STYLUS2: ButtonStylus2,
+	input.BTN_STYLUS3: ButtonStylus3,
+	input.BTN_LEFT:    ButtonLeft,
+	input.BTN_RIGHT:   ButtonRight,
+	input.BTN_MIDDLE:  ButtonMiddle,
+	input.BTN_SIDE:    ButtonSide,
+	input.BTN_EXTRA:   ButtonExtra,
+}
+
+var buttonOrder []input.KeyCode = []input.KeyCode{
+	input.BTN_STYLUS,
+	input.BTN_STYLUS2,
+	input.BTN_STYLUS3,
+	input.BTN_LEFT,
+	input.BTN_RIGHT,
+	input.BTN_MIDDLE,
+	input.BTN_SIDE,
+	input.BTN_EXTRA,
+}
+
+var toolNames []string = []string{
+	"none",
+	"pen",
+	"eraser",
+	"brush",
+	"pencil",
+	"airbrush",
+	"mouse",
+	"lens",
+}
+
+// String returns the lowercase name of the tool type.
+func (toolType ToolType) String() string {
+	if int(toolType) >= len(toolNames) {
+		return fmt.Sprintf("ToolType(%d)", uint8(toolType))
+	}
+
+	return toolNames[toolType]
+}
+
+// String returns a description of the tool, including its serial number
+// if known.
+func (tool Tool) String() string {
+	if tool.Serial == 0 {
+		return tool.Type.String()
+	}
+
+	return fmt.Sprintf("%s:%#x", tool.Type, tool.Serial)
+}
+
+// Has reports whether all buttons in other are in buttons.
+func (buttons Buttons) Has(other Buttons) bool {
+	return buttons&other == other
+}
+
+// String returns the names of the buttons in the set joined by "+".
+func (buttons Buttons) String() string {
+	var (
+		names []string
+		code  input.KeyCode
+	)
+
+	for _, code = range buttonOrder {
+		if buttons.Has(buttonCodes[code]) {
+			names = append(names, code.String())
+		}
+	}
+
+	return strings.Join(names, "+")
+}
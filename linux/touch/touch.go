@@ -0,0 +1,397 @@
+// Package touch injects multitouch gestures through virtual touchscreens
+// and touchpads. [NewDevice] creates a uinput device speaking the
+// type B (slotted) multitouch protocol, and a [Surface] turns contact
+// level calls such as [Surface.Down], [Surface.Move] and [Surface.Up]
+// into the slot, tracking ID, [input.BTN_TOUCH], [input.BTN_TOOL_FINGER]
+// and single-touch emulation events the kernel documents for real
+// hardware, so libinput and applications treat them as real touches.
+package touch
+
+import (
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+	"sync"
+
+	"github.com/andrieee44/gopkg/linux/uapi/input"
+	uapi "github.com/andrieee44/gopkg/linux/uapi/uinput"
+	"github.com/andrieee44/gopkg/linux/uinput"
+)
+
+// DefaultSlots is the number of contacts tracked when [Config].Slots is
+// zero.
+const DefaultSlots int32 = 10
+
+// maxTrackingID is the largest tracking ID before they wrap around.
+const maxTrackingID int32 = 0xffff
+
+var (
+	// ErrInvalidConfig is returned by [NewDevice] for a [Config] without
+	// a size.
+	ErrInvalidConfig error = errors.New("invalid touch device config")
+
+	// ErrContactDown is returned by [Surface.Down] for a contact that is
+	// already down.
+	ErrContactDown error = errors.New("contact is already down")
+
+	// ErrNoContact is returned by [Surface.Move] and [Surface.Up] for a
+	// contact that is not down.
+	ErrNoContact error = errors.New("contact is not down")
+
+	// ErrNoSlot is returned by [Surface.Down] when every slot is in use.
+	ErrNoSlot error = errors.New("no free multitouch slot")
+)
+
+// Kind selects the kind of device created by [NewDevice].
+type Kind int
+
+const (
+	// Touchscreen is a direct input device: touches land where they are
+	// on screen. It sets [input.INPUT_PROP_DIRECT].
+	Touchscreen Kind = iota
+
+	// Touchpad is an indirect device moving a pointer. It sets
+	// [input.INPUT_PROP_POINTER] and [input.INPUT_PROP_BUTTONPAD], and
+	// has a clickpad button, see [Surface.Button].
+	Touchpad
+)
+
+// Config describes the surface of a touch device.
+type Config struct {
+	// Width and Height are the largest X and Y coordinates. Coordinates
+	// start at zero.
+	Width, Height int32
+
+	// Resolution is the number of units per millimetre on both axes.
+	// Touchpads need it for gestures and palm detection to work.
+	Resolution int32
+
+	// Slots is the number of contacts that can be down at once. It
+	// defaults to [DefaultSlots].
+	Slots int32
+}
+
+// Surface tracks the contacts on a touch device and emits their events.
+// Its contacts change only once their events have been emitted, so a
+// failed call leaves it as it was. It is safe for concurrent use.
+type Surface struct {
+	emitter uinput.Emitter
+	state   surfaceState
+	mu      sync.Mutex
+}
+
+// surfaceState is the state of the contacts of a [Surface] as emitted.
+type surfaceState struct {
+	slots  []contact
+	byID   map[int]int32
+	slot   int32
+	nextID int32
+	serial uint64
+	tool   input.KeyCode
+}
+
+type contact struct {
+	down   bool
+	serial uint64
+	x, y   int32
+}
+
+// toolKeys lists the tool buttons reported for one to five contacts.
+var toolKeys = []input.KeyCode{
+	input.BTN_TOOL_FINGER,
+	input.BTN_TOOL_DOUBLETAP,
+	input.BTN_TOOL_TRIPLETAP,
+	input.BTN_TOOL_QUADTAP,
+	input.BTN_TOOL_QUINTTAP,
+}
+
+// NewDevice creates a uinput touch device of kind called name. Pass it
+// to [New] with the same cfg, and close it with [uinput.Device.Close] when
+// done.
+func NewDevice(kind Kind, name string, cfg Config) (*uinput.Device, error) {
+	var (
+		dev   *uinput.Device
+		props []input.PropCode
+		keys  []input.KeyCode
+		steps []func() error
+		step  func() error
+		err   error
+	)
+
+	cfg, err = cfg.withDefaults()
+	if err != nil {
+		return nil, err
+	}
+
+	dev, err = uinput.NewDevice(input.ID{Bustype: input.BUS_VIRTUAL}, name)
+	if err != nil {
+		return nil, err
+	}
+
+	keys = append([]input.KeyCode{input.BTN_TOUCH}, toolKeys...)
+	props = []input.PropCode{input.INPUT_PROP_DIRECT}
+
+	if kind == Touchpad {
+		keys = append(keys, input.BTN_LEFT)
+		props = []input.PropCode{input.INPUT_PROP_POINTER, input.INPUT_PROP_BUTTONPAD}
+	}
+
+	steps = []func() error{
+		func() error { return dev.SetEvents([]input.EventCode{input.EV_KEY, input.EV_ABS}) },
+		func() error { return dev.SetKeys(keys) },
+		func() error { return dev.SetProps(props) },
+		func() error { return dev.SetAbsInfos(cfg.absInfos()) },
+		dev.Create,
+	}
+
+	for _, step = range steps {
+		err = step()
+		if err != nil {
+			return nil, errors.Join(err, dev.Close())
+		}
+	}
+
+	return dev, nil
+}
+
+// New returns a [Surface] emitting to emitter, which must support the
+// events of a device made by [NewDevice] with cfg.
+func New(emitter uinput.Emitter, cfg Config) *Surface {
+	if cfg.Slots <= 0 {
+		cfg.Slots = DefaultSlots
+	}
+
+	return &Surface{
+		emitter: emitter,
+		state: surfaceState{
+			slots: make([]contact, cfg.Slots),
+			byID:  make(map[int]int32),
+		},
+	}
+}
+
+// Down puts contact id down at x and y in the lowest free slot and
+// emits the frame.
+func (surface *Surface) Down(id int, x, y int32) error {
+	var (
+		next   surfaceState
+		events []input.Event
+		slot   int32
+		ok     bool
+	)
+
+	surface.mu.Lock()
+	defer surface.mu.Unlock()
+
+	_, ok = surface.state.byID[id]
+	if ok {
+		return fmt.Errorf("failed to put contact %d down: %w", id, ErrContactDown)
+	}
+
+	slot = int32(slices.IndexFunc(surface.state.slots, func(other contact) bool {
+		return !other.down
+	}))
+	if slot < 0 {
+		return fmt.Errorf("failed to put contact %d down: %w", id, ErrNoSlot)
+	}
+
+	next = surface.state.clone()
+	next.serial++
+	next.byID[id] = slot
+	next.slots[slot] = contact{down: true, serial: next.serial, x: x, y: y}
+
+	events = next.selectSlot(slot)
+	events = append(
+		events,
+		uinput.AbsEvent(input.ABS_MT_TRACKING_ID, next.nextID),
+		uinput.AbsEvent(input.ABS_MT_POSITION_X, x),
+		uinput.AbsEvent(input.ABS_MT_POSITION_Y, y),
+	)
+
+	next.nextID = (next.nextID + 1) & maxTrackingID
+
+	return surface.emit(next, events)
+}
+
+// Move moves contact id to x and y and emits the frame.
+func (surface *Surface) Move(id int, x, y int32) error {
+	var (
+		next   surfaceState
+		events []input.Event
+		slot   int32
+		ok     bool
+	)
+
+	surface.mu.Lock()
+	defer surface.mu.Unlock()
+
+	slot, ok = surface.state.byID[id]
+	if !ok {
+		return fmt.Errorf("failed to move contact %d: %w", id, ErrNoContact)
+	}
+
+	next = surface.state.clone()
+	next.slots[slot].x, next.slots[slot].y = x, y
+
+	events = next.selectSlot(slot)
+	events = append(
+		events,
+		uinput.AbsEvent(input.ABS_MT_POSITION_X, x),
+		uinput.AbsEvent(input.ABS_MT_POSITION_Y, y),
+	)
+
+	return surface.emit(next, events)
+}
+
+// Up lifts contact id and emits the frame.
+func (surface *Surface) Up(id int) error {
+	var (
+		next   surfaceState
+		events []input.Event
+		slot   int32
+		ok     bool
+	)
+
+	surface.mu.Lock()
+	defer surface.mu.Unlock()
+
+	slot, ok = surface.state.byID[id]
+	if !ok {
+		return fmt.Errorf("failed to lift contact %d: %w", id, ErrNoContact)
+	}
+
+	next = surface.state.clone()
+	delete(next.byID, id)
+	next.slots[slot] = contact{}
+
+	events = next.selectSlot(slot)
+	events = append(events, uinput.AbsEvent(input.ABS_MT_TRACKING_ID, -1))
+
+	return surface.emit(next, events)
+}
+
+// Button presses or releases the clickpad button of a [Touchpad].
+func (surface *Surface) Button(pressed bool) error {
+	surface.mu.Lock()
+	defer surface.mu.Unlock()
+
+	return surface.emitter.EmitBatch([]input.Event{
+		uinput.PressEvent(input.BTN_LEFT, pressed),
+		uinput.SyncEvent(),
+	})
+}
+
+// Contacts returns the number of contacts down.
+func (surface *Surface) Contacts() int {
+	surface.mu.Lock()
+	defer surface.mu.Unlock()
+
+	return len(surface.state.byID)
+}
+
+// emit completes a frame of slot events with the touch and tool buttons
+// and the single-touch position of the oldest contact of next, and sends
+// it. next becomes the state of the surface once the frame is sent.
+func (surface *Surface) emit(next surfaceState, events []input.Event) error {
+	var (
+		count   int
+		tool    input.KeyCode
+		oldest  *contact
+		idx     int
+		touched bool
+		err     error
+	)
+
+	count = len(next.byID)
+	if count > 0 {
+		tool = toolKeys[min(count, len(toolKeys))-1]
+	}
+
+	touched = next.tool != 0
+	if (count > 0) != touched {
+		events = append(events, uinput.PressEvent(input.BTN_TOUCH, count > 0))
+	}
+
+	if tool != next.tool {
+		if next.tool != 0 {
+			events = append(events, uinput.PressEvent(next.tool, false))
+		}
+
+		if tool != 0 {
+			events = append(events, uinput.PressEvent(tool, true))
+		}
+
+		next.tool = tool
+	}
+
+	for idx = range next.slots {
+		if next.slots[idx].down && (oldest == nil || next.slots[idx].serial < oldest.serial) {
+			oldest = &next.slots[idx]
+		}
+	}
+
+	if oldest != nil {
+		events = append(
+			events,
+			uinput.AbsEvent(input.ABS_X, oldest.x),
+			uinput.AbsEvent(input.ABS_Y, oldest.y),
+		)
+	}
+
+	err = surface.emitter.EmitBatch(append(events, uinput.SyncEvent()))
+	if err != nil {
+		return err
+	}
+
+	surface.state = next
+
+	return nil
+}
+
+// clone returns a copy of state that can be changed without changing
+// state.
+func (state surfaceState) clone() surfaceState {
+	state.slots = slices.Clone(state.slots)
+	state.byID = maps.Clone(state.byID)
+
+	return state
+}
+
+func (state *surfaceState) selectSlot(slot int32) []input.Event {
+	if slot == state.slot {
+		return nil
+	}
+
+	state.slot = slot
+
+	return []input.Event{uinput.AbsEvent(input.ABS_MT_SLOT, slot)}
+}
+
+func (cfg Config) withDefaults() (Config, error) {
+	if cfg.Width <= 0 || cfg.Height <= 0 {
+		return cfg, fmt.Errorf("size %dx%d: %w", cfg.Width, cfg.Height, ErrInvalidConfig)
+	}
+
+	if cfg.Slots <= 0 {
+		cfg.Slots = DefaultSlots
+	}
+
+	return cfg, nil
+}
+
+func (cfg Config) absInfos() []uapi.AbsSetup {
+	var x, y input.AbsInfo
+
+	x = input.AbsInfo{Maximum: cfg.Width, Resolution: cfg.Resolution}
+	y = input.AbsInfo{Maximum: cfg.Height, Resolution: cfg.Resolution}
+
+	return []uapi.AbsSetup{
+		{Code: input.ABS_X, AbsInfo: x},
+		{Code: input.ABS_Y, AbsInfo: y},
+		{Code: input.ABS_MT_SLOT, AbsInfo: input.AbsInfo{Maximum: cfg.Slots - 1}},
+		{Code: input.ABS_MT_TRACKING_ID, AbsInfo: input.AbsInfo{Maximum: maxTrackingID}},
+		{Code: input.ABS_MT_POSITION_X, AbsInfo: x},
+		{Code: input.ABS_MT_POSITION_Y, AbsInfo: y},
+	}
+}
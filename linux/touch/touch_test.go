@@ -0,0 +1,172 @@
+package touch_test
+
+import (
+	"errors"
+	"slices"
+	"testing"
+
+	"github.com/andrieee44/gopkg/linux/touch"
+	"github.com/andrieee44/gopkg/linux/uinput/uinputtest"
+)
+
+func TestSurface(t *testing.T) {
+	var (
+		rec     *uinputtest.Recorder
+		surface *touch.Surface
+		exp     [][]string
+		frames  [][]string
+		idx     int
+		err     error
+	)
+
+	t.Parallel()
+
+	rec = new(uinputtest.Recorder)
+	surface = touch.New(rec, touch.Config{Slots: 2})
+
+	err = errors.Join(
+		surface.Down(7, 10, 20),
+		surface.Down(9, 30, 40),
+		surface.Move(9, 31, 41),
+		surface.Up(7),
+		surface.Up(9),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	exp = [][]string{
+		{
+			"ABS_MT_TRACKING_ID 0",
+			"ABS_MT_POSITION_X 10",
+			"ABS_MT_POSITION_Y 20",
+			"BTN_TOUCH 1",
+			"BTN_TOOL_FINGER 1",
+			"ABS_X 10",
+			"ABS_Y 20",
+		},
+		{
+			"ABS_MT_SLOT 1",
+			"ABS_MT_TRACKING_ID 1",
+			"ABS_MT_POSITION_X 30",
+			"ABS_MT_POSITION_Y 40",
+			"BTN_TOOL_FINGER 0",
+			"BTN_TOOL_DOUBLETAP 1",
+			"ABS_X 10",
+			"ABS_Y 20",
+		},
+		{"ABS_MT_POSITION_X 31", "ABS_MT_POSITION_Y 41", "ABS_X 10", "ABS_Y 20"},
+		{
+			"ABS_MT_SLOT 0",
+			"ABS_MT_TRACKING_ID -1",
+			"BTN_TOOL_DOUBLETAP 0",
+			"BTN_TOOL_FINGER 1",
+			"ABS_X 31",
+			"ABS_Y 41",
+		},
+		{"ABS_MT_SLOT 1", "ABS_MT_TRACKING_ID -1", "BTN_TOUCH 0", "BTN_TOOL_FINGER 0"},
+	}
+
+	frames = rec.Frames()
+	if len(frames) != len(exp) {
+		t.Fatalf("frames: got: %d, exp: %d", len(frames), len(exp))
+	}
+
+	for idx = range exp {
+		if !slices.Equal(frames[idx], exp[idx]) {
+			t.Errorf("frame %d: got: %v, exp: %v", idx, frames[idx], exp[idx])
+		}
+	}
+
+	if surface.Contacts() != 0 {
+		t.Errorf("contacts: got: %d, exp: 0", surface.Contacts())
+	}
+}
+
+func TestSurfaceErrors(t *testing.T) {
+	var (
+		surface *touch.Surface
+		err     error
+	)
+
+	t.Parallel()
+
+	surface = touch.New(new(uinputtest.Recorder), touch.Config{Slots: 1})
+
+	err = surface.Down(1, 0, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = surface.Down(1, 0, 0)
+	if !errors.Is(err, touch.ErrContactDown) {
+		t.Errorf("down twice: got: %v, exp: %v", err, touch.ErrContactDown)
+	}
+
+	err = surface.Down(2, 0, 0)
+	if !errors.Is(err, touch.ErrNoSlot) {
+		t.Errorf("no slot: got: %v, exp: %v", err, touch.ErrNoSlot)
+	}
+
+	err = surface.Move(2, 0, 0)
+	if !errors.Is(err, touch.ErrNoContact) {
+		t.Errorf("move: got: %v, exp: %v", err, touch.ErrNoContact)
+	}
+
+	err = surface.Up(2)
+	if !errors.Is(err, touch.ErrNoContact) {
+		t.Errorf("up: got: %v, exp: %v", err, touch.ErrNoContact)
+	}
+}
+
+func TestSurfaceEmitError(t *testing.T) {
+	var (
+		rec     *uinputtest.Recorder
+		surface *touch.Surface
+		exp     []string
+		errEmit error
+		err     error
+	)
+
+	t.Parallel()
+
+	rec = new(uinputtest.Recorder)
+	errEmit = errors.New("emit failed")
+	rec.SetErr(errEmit)
+	surface = touch.New(rec, touch.Config{Slots: 2})
+
+	err = surface.Down(1, 10, 20)
+	if !errors.Is(err, errEmit) {
+		t.Fatalf("down: got: %v, exp: %v", err, errEmit)
+	}
+
+	if surface.Contacts() != 0 {
+		t.Errorf("contacts: got: %d, exp: 0", surface.Contacts())
+	}
+
+	err = surface.Up(1)
+	if !errors.Is(err, touch.ErrNoContact) {
+		t.Errorf("up: got: %v, exp: %v", err, touch.ErrNoContact)
+	}
+
+	rec.SetErr(nil)
+
+	err = surface.Down(1, 10, 20)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	exp = []string{
+		"ABS_MT_TRACKING_ID 0",
+		"ABS_MT_POSITION_X 10",
+		"ABS_MT_POSITION_Y 20",
+		"BTN_TOUCH 1",
+		"BTN_TOOL_FINGER 1",
+		"ABS_X 10",
+		"ABS_Y 20",
+	}
+
+	if len(rec.Frames()) != 1 || !slices.Equal(rec.Frames()[0], exp) {
+		t.Errorf("got: %v, exp: %v", rec.Frames(), [][]string{exp})
+	}
+}
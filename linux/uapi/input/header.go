@@ -33,8 +33,8 @@ type Event struct {
 // ID identifies an input device by its bus type, vendor id, product id,
 // and version.
 type ID struct {
-	// Bustype is the bus type for the device.
-	Bustype uint16
+	// Bustype is the bus type for the device, such as [BUS_USB].
+	Bustype BusCode
 
 	// Vendor is the vendor identifier assigned by the bus.
 	Vendor uint16
@@ -343,7 +343,7 @@ type FFPeriodicEffect struct {
 
 	// CustomLen is the number of samples in CustomData when Waveform is
 	// [FF_CUSTOM].
-	CustomLen uint16
+	CustomLen uint32
 
 	// CustomData points to a buffer of raw samples for a custom waveform.
 	// The driver copies this data, so it can be released after uploading.
@@ -406,7 +406,7 @@ type FFEffect struct {
 	Type uint16
 
 	// ID is the effect identifier. Set to -1 when creating a new effect.
-	ID BusCode
+	ID int16
 
 	// Direction is the force direction encoded in [0x0000..0xFFFF].
 	Direction uint16
@@ -418,7 +418,7 @@ type FFEffect struct {
 	Replay FFReplay
 
 	// Effect holds effect-specific parameters as a raw union payload.
-	Effect [32]byte
+	Effect FFEffectUnion
 }
 
 const (
@@ -2,6 +2,8 @@
 
 package input
 
+import "time"
+
 // InputEventTime stores the timestamp of an input event for 32‑bit
 // architectures such as 386 and arm. It matches the layout used
 // by the Linux kernel’s input_event struct for these platforms.
@@ -15,3 +17,24 @@ type EventTime struct {
 	// precision for the event timestamp.
 	Usec uint64
 }
+
+// NewEventTime converts ts to an [EventTime], truncating it to microsecond
+// precision.
+func NewEventTime(ts time.Time) EventTime {
+	return EventTime{
+		Sec:  uint64(ts.Unix()),
+		Usec: uint64(ts.Nanosecond() / int(time.Microsecond)),
+	}
+}
+
+// Time converts the timestamp to a [time.Time].
+func (eventTime EventTime) Time() time.Time {
+	return time.Unix(int64(eventTime.Sec), int64(eventTime.Usec)*int64(time.Microsecond))
+}
+
+// FFEffectUnion holds the raw bytes of the effect-specific union in
+// [FFEffect] for 32-bit architectures. Its largest member,
+// [FFPeriodicEffect], ends with a 4-byte length and a 4-byte pointer, so
+// it is 28 bytes long and 4-byte aligned, matching the layout of the C
+// struct ff_effect on these platforms, which is 44 bytes long.
+type FFEffectUnion [7]uint32
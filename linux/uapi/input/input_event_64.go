@@ -2,6 +2,8 @@
 
 package input
 
+import "time"
+
 // EventTime stores the timestamp of an input event for 64‑bit
 // architectures such as amd64 and arm64. It matches the layout used
 // by the Linux kernel’s input_event struct for these platforms.
@@ -15,3 +17,23 @@ type EventTime struct {
 	// precision for the event timestamp.
 	Usec int64
 }
+
+// NewEventTime converts ts to an [EventTime], truncating it to microsecond
+// precision.
+func NewEventTime(ts time.Time) EventTime {
+	return EventTime{
+		Sec:  int64(ts.Unix()),
+		Usec: int64(ts.Nanosecond() / int(time.Microsecond)),
+	}
+}
+
+// Time converts the timestamp to a [time.Time].
+func (eventTime EventTime) Time() time.Time {
+	return time.Unix(int64(eventTime.Sec), int64(eventTime.Usec)*int64(time.Microsecond))
+}
+
+// FFEffectUnion holds the raw bytes of the effect-specific union in
+// [FFEffect] for 64-bit architectures. The union contains a pointer in
+// [FFPeriodicEffect], so it is 32 bytes long and 8-byte aligned, matching
+// the layout of the C struct ff_effect on these platforms.
+type FFEffectUnion [4]uint64
@@ -1,6 +1,7 @@
 package input
 
 import (
+	"encoding/binary"
 	"errors"
 	"fmt"
 	"math"
@@ -191,3 +192,32 @@ func PrettifyEvent(event Event) (EventPretty, error) {
 		Value: event.Value,
 	}, nil
 }
+
+// Rumble returns the union as the [FFRumbleEffect] of an [FF_RUMBLE]
+// effect.
+func (union FFEffectUnion) Rumble() FFRumbleEffect {
+	var (
+		buf    []byte
+		rumble FFRumbleEffect
+	)
+
+	buf, _ = binary.Append(nil, binary.NativeEndian, union)
+	_, _ = binary.Decode(buf, binary.NativeEndian, &rumble)
+
+	return rumble
+}
+
+// NewFFRumbleUnion returns the [FFEffectUnion] of an [FF_RUMBLE] effect
+// with the given rumble parameters.
+func NewFFRumbleUnion(rumble FFRumbleEffect) FFEffectUnion {
+	var (
+		buf   []byte
+		union FFEffectUnion
+	)
+
+	buf, _ = binary.Append(nil, binary.NativeEndian, rumble)
+	buf = append(buf, make([]byte, binary.Size(union)-len(buf))...)
+	_, _ = binary.Decode(buf, binary.NativeEndian, &union)
+
+	return union
+}
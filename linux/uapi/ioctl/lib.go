@@ -3,6 +3,7 @@
 package ioctl
 
 import (
+	"errors"
 	"fmt"
 	"syscall"
 	"unsafe"
@@ -11,6 +12,11 @@ import (
 	"golang.org/x/sys/unix"
 )
 
+// ErrSizeOverflow is returned when a size or identifier does not fit in
+// the field it must be encoded into, such as a buffer length exceeding the
+// 32-bit ioctl size argument.
+var ErrSizeOverflow error = errors.New("size overflow")
+
 // GetAny performs an ioctl call on the given file descriptor using a
 // request code from reqFn.
 //
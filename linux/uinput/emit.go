@@ -0,0 +1,266 @@
+package uinput
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/andrieee44/gopkg/linux/uapi/input"
+)
+
+// ScrollDetent is the high-resolution wheel value of one wheel detent, as
+// used by [input.REL_WHEEL_HI_RES] and [Device.Scroll].
+const ScrollDetent int32 = 120
+
+// ErrUnsupportedCode is returned when emitting an event whose type or code
+// was not enabled on the [Device] before [Device.Create].
+var ErrUnsupportedCode error = errors.New("code is not enabled on the device")
+
+// Emitter receives batches of events, such as the frames of a [Merger]
+// or [Repeater]. [Device] and [Repeater] implement it, and so can fakes
+// in tests.
+type Emitter interface {
+	EmitBatch(events []input.Event) error
+}
+
+// Emit writes a single event. Its timestamp is set to the current time
+// and its type and code are checked against the capabilities enabled with
+// the Set* methods, returning [ErrUnsupportedCode] for others. Listeners
+// see the event only after the next [Device.Sync].
+func (dev *Device) Emit(event input.Event) error {
+	return dev.EmitBatch([]input.Event{event})
+}
+
+// EmitBatch writes events with a single write, like [Device.Emit]. No
+// event is written if any of them is not supported. EmitBatch does not
+// append a [input.SYN_REPORT]; include one, or call [Device.Sync], to
+// end the frame.
+func (dev *Device) EmitBatch(events []input.Event) error {
+	var (
+		now   input.EventTime
+		batch []input.Event
+		idx   int
+		err   error
+	)
+
+	now = input.NewEventTime(time.Now())
+	batch = make([]input.Event, len(events))
+
+	for idx = range events {
+		err = dev.check(events[idx].Type, events[idx].Code)
+		if err != nil {
+			return err
+		}
+
+		batch[idx] = events[idx]
+		batch[idx].Time = now
+	}
+
+	return dev.writeEvents(batch)
+}
+
+// Sync emits a [input.SYN_REPORT], ending the current frame of events.
+func (dev *Device) Sync() error {
+	return dev.Emit(SyncEvent())
+}
+
+// KeyDown presses key and syncs.
+func (dev *Device) KeyDown(key input.KeyCode) error {
+	return dev.EmitBatch([]input.Event{KeyEvent(key, 1), SyncEvent()})
+}
+
+// KeyUp releases key and syncs.
+func (dev *Device) KeyUp(key input.KeyCode) error {
+	return dev.EmitBatch([]input.Event{KeyEvent(key, 0), SyncEvent()})
+}
+
+// Tap presses and releases key, syncing after each, so listeners see two
+// separate frames.
+func (dev *Device) Tap(key input.KeyCode) error {
+	return dev.EmitBatch([]input.Event{
+		KeyEvent(key, 1),
+		SyncEvent(),
+		KeyEvent(key, 0),
+		SyncEvent(),
+	})
+}
+
+// MoveRel moves the pointer by dx and dy with [input.REL_X] and
+// [input.REL_Y] and syncs. A zero delta is not emitted.
+func (dev *Device) MoveRel(dx, dy int32) error {
+	var events []input.Event
+
+	if dx != 0 {
+		events = append(events, RelEvent(input.REL_X, dx))
+	}
+
+	if dy != 0 {
+		events = append(events, RelEvent(input.REL_Y, dy))
+	}
+
+	if len(events) == 0 {
+		return nil
+	}
+
+	return dev.EmitBatch(append(events, SyncEvent()))
+}
+
+// Scroll scrolls by vertical and horizontal, given in high-resolution units
+// of which [ScrollDetent] make one wheel detent; positive values scroll up
+// and right. If the device has [input.REL_WHEEL_HI_RES] or
+// [input.REL_HWHEEL_HI_RES] enabled the values are emitted on them as is.
+// Whole detents are emitted on [input.REL_WHEEL] and [input.REL_HWHEEL],
+// keeping fractions for later calls, so applications that only read the
+// classic wheel scroll as well. Scroll returns [ErrUnsupportedCode] if
+// neither wheel of a non-zero direction is enabled.
+func (dev *Device) Scroll(vertical, horizontal int32) error {
+	var (
+		events, hEvents []input.Event
+		err             error
+	)
+
+	dev.scrollMu.Lock()
+	defer dev.scrollMu.Unlock()
+
+	events, err = dev.scrollAxis(
+		vertical,
+		&dev.scroll[0],
+		input.REL_WHEEL,
+		input.REL_WHEEL_HI_RES,
+	)
+	if err != nil {
+		return err
+	}
+
+	hEvents, err = dev.scrollAxis(
+		horizontal,
+		&dev.scroll[1],
+		input.REL_HWHEEL,
+		input.REL_HWHEEL_HI_RES,
+	)
+	if err != nil {
+		return err
+	}
+
+	events = append(events, hEvents...)
+
+	if len(events) == 0 {
+		return nil
+	}
+
+	return dev.EmitBatch(append(events, SyncEvent()))
+}
+
+// MoveAbs moves to x and y on [input.ABS_X] and [input.ABS_Y] and syncs.
+func (dev *Device) MoveAbs(x, y int32) error {
+	return dev.EmitBatch([]input.Event{
+		{Type: input.EV_ABS, Code: uint16(input.ABS_X), Value: x},
+		{Type: input.EV_ABS, Code: uint16(input.ABS_Y), Value: y},
+		SyncEvent(),
+	})
+}
+
+// Switch sets the state of the switch sw, such as [input.SW_LID], and
+// syncs.
+func (dev *Device) Switch(sw input.SwitchCode, on bool) error {
+	var value int32
+
+	if on {
+		value = 1
+	}
+
+	return dev.EmitBatch([]input.Event{
+		{Type: input.EV_SW, Code: uint16(sw), Value: value},
+		SyncEvent(),
+	})
+}
+
+func (dev *Device) scrollAxis(
+	value int32,
+	remainder *int32,
+	wheel, hiRes input.RelativeCode,
+) ([]input.Event, error) {
+	var (
+		events       []input.Event
+		hasWheel     bool
+		hasHiRes     bool
+		detents, sum int32
+	)
+
+	if value == 0 {
+		return nil, nil
+	}
+
+	hasWheel = dev.check(input.EV_REL, uint16(wheel)) == nil
+	hasHiRes = dev.check(input.EV_REL, uint16(hiRes)) == nil
+
+	if !hasWheel && !hasHiRes {
+		return nil, fmt.Errorf("failed to scroll: %s: %w", wheel, ErrUnsupportedCode)
+	}
+
+	if hasHiRes {
+		events = append(events, RelEvent(hiRes, value))
+	}
+
+	if hasWheel {
+		sum = *remainder + value
+		detents = sum / ScrollDetent
+		*remainder = sum - detents*ScrollDetent
+
+		if detents != 0 {
+			events = append(events, RelEvent(wheel, detents))
+		}
+	}
+
+	return events, nil
+}
+
+func (dev *Device) check(event input.EventCode, code uint16) error {
+	switch {
+	case event == input.EV_SYN:
+		return nil
+	case !dev.events[event]:
+		return fmt.Errorf("failed to emit uinput event: %s: %w", event, ErrUnsupportedCode)
+	case event != input.EV_REP && !dev.codes[event][code]:
+		return fmt.Errorf(
+			"failed to emit uinput event: %s code %d: %w",
+			event,
+			code,
+			ErrUnsupportedCode,
+		)
+	}
+
+	return nil
+}
+
+// SyncEvent returns a [input.SYN_REPORT] event, ending a frame.
+func SyncEvent() input.Event {
+	return input.Event{Type: input.EV_SYN, Code: uint16(input.SYN_REPORT)}
+}
+
+// KeyEvent returns an event setting key to value: 1 for a press, 0 for a
+// release and 2 for a repeat.
+func KeyEvent(key input.KeyCode, value int32) input.Event {
+	return input.Event{Type: input.EV_KEY, Code: uint16(key), Value: value}
+}
+
+// PressEvent returns an event pressing or releasing key.
+func PressEvent(key input.KeyCode, pressed bool) input.Event {
+	var value int32
+
+	if pressed {
+		value = 1
+	}
+
+	return KeyEvent(key, value)
+}
+
+// RelEvent returns an event moving the relative axis rel by value.
+func RelEvent(rel input.RelativeCode, value int32) input.Event {
+	return input.Event{Type: input.EV_REL, Code: uint16(rel), Value: value}
+}
+
+// AbsEvent returns an event setting the absolute axis abs to value.
+func AbsEvent(abs input.AbsoluteCode, value int32) input.Event {
+	return input.Event{Type: input.EV_ABS, Code: uint16(abs), Value: value}
+}
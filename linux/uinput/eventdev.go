@@ -0,0 +1,89 @@
+package uinput
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"syscall"
+	"time"
+
+	"github.com/andrieee44/gopkg/linux/evdev"
+	"github.com/andrieee44/gopkg/linux/inputerr"
+)
+
+// SysInputDir is the sysfs directory holding the input devices created
+// through uinput, named by the sysname returned by [Device.Name].
+const SysInputDir string = "/sys/devices/virtual/input"
+
+// eventDevicePoll is how often [Device.EventDevice] checks for the event
+// device node.
+const eventDevicePoll time.Duration = 10 * time.Millisecond
+
+// EventDevice opens the event device node of the created [Device], such
+// as /dev/input/event7, for reading. The node is found through the
+// device's directory in [SysInputDir]. It appears shortly after
+// [Device.Create] returns and may only become readable once udev has
+// applied its permissions, so EventDevice keeps trying until it succeeds,
+// fails with another error or ctx is done. The caller closes the returned
+// device.
+func (dev *Device) EventDevice(ctx context.Context) (*evdev.Device, error) {
+	var (
+		sysname string
+		evDev   *evdev.Device
+		timer   *time.Timer
+		err     error
+	)
+
+	sysname, err = dev.Name()
+	if err != nil {
+		return nil, err
+	}
+
+	timer = time.NewTimer(0)
+	defer timer.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil, fmt.Errorf(
+				"failed to open event device of %s: %w",
+				sysname,
+				errors.Join(ctx.Err(), err),
+			)
+		case <-timer.C:
+		}
+
+		evDev, err = openEventDevice(filepath.Join(SysInputDir, sysname))
+		if err == nil {
+			return evDev, nil
+		}
+
+		if !errors.Is(err, inputerr.ErrNotFound) && !errors.Is(err, inputerr.ErrPermission) {
+			return nil, err
+		}
+
+		timer.Reset(eventDevicePoll)
+	}
+}
+
+// openEventDevice opens the event device node listed in the sysfs
+// directory of an input device.
+func openEventDevice(dir string) (*evdev.Device, error) {
+	var (
+		matches []string
+		err     error
+	)
+
+	matches, err = filepath.Glob(filepath.Join(dir, "event*"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to find event device in %s: %w", dir, err)
+	}
+
+	if len(matches) == 0 {
+		return nil, inputerr.Wrap("failed to find event device", dir, syscall.ENOENT)
+	}
+
+	return evdev.OpenDevice(filepath.Join("/dev/input", filepath.Base(matches[0])), os.O_RDONLY)
+}
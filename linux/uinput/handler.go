@@ -0,0 +1,141 @@
+package uinput
+
+import (
+	"errors"
+	"maps"
+	"slices"
+	"syscall"
+
+	"github.com/andrieee44/gopkg/linux/uapi/input"
+)
+
+// FFHandler stores and plays the force feedback effects applications
+// send to a [Device], such as the rumble effects of a game. Its methods
+// are called from the device's event loop. Upload and Erase answer the
+// kernel: a [syscall.Errno] error is passed on as is and any other error
+// as EINVAL. If they take longer than the timeout set with
+// [Device.SetFFTimeout], the request fails with ETIMEDOUT and the late
+// call keeps running, so they may run concurrently with later calls.
+type FFHandler interface {
+	// Upload stores effect, assigned an ID by the kernel below the
+	// maximum passed to [Device.SetFFHandler]. If an effect with the same
+	// ID is being updated, old holds it.
+	Upload(effect, old input.FFEffect) error
+
+	// Erase removes the effect with the given ID.
+	Erase(id int16) error
+
+	// Play is called when effect id is started, to be played count times
+	// in a row, or stopped, with count 0. It should return quickly.
+	Play(id int16, count int32)
+}
+
+// Handler receives the events the kernel sends back to a [Device] when
+// applications write to its event device, such as a compositor turning on
+// Caps Lock. Its methods are called one at a time from the device's event
+// loop and should return quickly; a slow handler delays force feedback
+// requests. Embed [NopHandler] to implement only some of them.
+type Handler interface {
+	// LED is called when an LED such as [input.LED_CAPSL] is turned on
+	// or off.
+	LED(code input.LEDCode, on bool)
+
+	// Sound is called when a sound such as [input.SND_BELL] is started,
+	// with a non-zero value, or stopped.
+	Sound(code input.SoundCode, value int32)
+}
+
+// NopHandler is a [Handler] ignoring every event.
+type NopHandler struct{}
+
+// LED does nothing.
+func (NopHandler) LED(input.LEDCode, bool) {}
+
+// Sound does nothing.
+func (NopHandler) Sound(input.SoundCode, int32) {}
+
+// SetHandler sets the [Handler] receiving the [input.EV_LED] and
+// [input.EV_SND] events sent to the device. It must be called before
+// [Device.Create], which starts delivering them.
+func (dev *Device) SetHandler(handler Handler) {
+	dev.handler = handler
+}
+
+// Playing reports whether force feedback effect id is playing: it has
+// been started and not stopped or erased since. Effects started with a
+// finite count are reported as playing until stopped, as the kernel does
+// not announce their end.
+func (dev *Device) Playing(id int16) bool {
+	dev.playingMu.Lock()
+	defer dev.playingMu.Unlock()
+
+	return dev.playing[id] > 0
+}
+
+// PlayingEffects returns the IDs of the effects that are [Device.Playing],
+// in ascending order.
+func (dev *Device) PlayingEffects() []int16 {
+	dev.playingMu.Lock()
+	defer dev.playingMu.Unlock()
+
+	return slices.Sorted(maps.Keys(dev.playing))
+}
+
+// handle tracks and delivers an event sent back by the kernel. Force
+// feedback events for codes beyond the effect IDs, such as
+// [input.FF_GAIN], are dropped.
+func (dev *Device) handle(event input.Event) {
+	if event.Type == input.EV_FF {
+		if uint32(event.Code) >= dev.setup.FFEffectsMax || dev.ffHandler == nil {
+			return
+		}
+
+		dev.setPlaying(int16(event.Code), event.Value)
+		dev.ffHandler.Play(int16(event.Code), event.Value)
+
+		return
+	}
+
+	if dev.handler == nil {
+		return
+	}
+
+	switch event.Type {
+	case input.EV_LED:
+		dev.handler.LED(input.LEDCode(event.Code), event.Value != 0)
+	case input.EV_SND:
+		dev.handler.Sound(input.SoundCode(event.Code), event.Value)
+	}
+}
+
+func (dev *Device) setPlaying(id int16, count int32) {
+	dev.playingMu.Lock()
+	defer dev.playingMu.Unlock()
+
+	if count <= 0 {
+		delete(dev.playing, id)
+
+		return
+	}
+
+	if dev.playing == nil {
+		dev.playing = make(map[int16]int32)
+	}
+
+	dev.playing[id] = count
+}
+
+// retval converts the result of an [FFHandler] request to the negated
+// errno the kernel expects.
+func retval(err error) int32 {
+	var errno syscall.Errno
+
+	switch {
+	case err == nil:
+		return 0
+	case errors.As(err, &errno):
+		return -int32(errno)
+	default:
+		return -int32(syscall.EINVAL)
+	}
+}
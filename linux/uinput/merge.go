@@ -0,0 +1,428 @@
+package uinput
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+	"sync"
+
+	"github.com/andrieee44/gopkg/linux/evdev"
+	"github.com/andrieee44/gopkg/linux/internal/evdevmux"
+	"github.com/andrieee44/gopkg/linux/uapi/input"
+)
+
+// Merger combines the events of several source devices into one stream,
+// such as the halves of a split keyboard or a keyboard and a macro pad
+// that should appear to applications as a single device. Events are
+// collected per source and passed on a whole frame at a time, so frames
+// of different sources never interleave. Keys are tracked per source: a
+// key held on two sources is pressed once and released only when both
+// let go. Key repeats from the sources are dropped, as a merged device
+// with [input.EV_REP] repeats keys itself. Absolute axes are passed on as
+// they are, so sources must not share multitouch axes. It is safe for
+// concurrent use.
+type Merger struct {
+	emitter Emitter
+	frames  [][]input.Event
+	dropped []bool
+	held    map[input.KeyCode]map[int]bool
+	mu      sync.Mutex
+}
+
+// ledForwarder is a [Handler] passing the LEDs set on a merged device on
+// to the sources that have them, so Caps Lock lights up on every half of
+// a split keyboard.
+type ledForwarder struct {
+	NopHandler
+
+	sources []*evdev.Device
+	leds    []map[input.LEDCode]bool
+}
+
+// NewMerger returns a [Merger] for sources source devices, numbered from
+// zero, emitting to emitter.
+func NewMerger(emitter Emitter, sources int) *Merger {
+	return &Merger{
+		emitter: emitter,
+		frames:  make([][]input.Event, sources),
+		dropped: make([]bool, sources),
+		held:    make(map[input.KeyCode]map[int]bool),
+	}
+}
+
+// NewMergedDevice creates a uinput device called name with the combined
+// capabilities of sources, as merged by [MergeSnapshots], for a [Merger]
+// to emit to. Force feedback is left out. LEDs set on it, such as Caps
+// Lock, are set on every source that has them, which needs sources opened
+// for writing. Close it with [Device.Close] when done.
+func NewMergedDevice(name string, sources []*evdev.Device) (*Device, error) {
+	var (
+		snaps   []*evdev.Snapshot
+		snap    *evdev.Snapshot
+		source  *evdev.Device
+		leds    []map[input.LEDCode]bool
+		dev     *Device
+		virtual input.ID
+		err     error
+	)
+
+	for _, source = range sources {
+		snap, err = source.Snapshot()
+		if err != nil {
+			return nil, fmt.Errorf("failed to merge devices: %w", err)
+		}
+
+		snaps = append(snaps, snap)
+		leds = append(leds, snap.LED)
+	}
+
+	virtual = input.ID{Bustype: input.BUS_VIRTUAL}
+
+	dev, err = NewDeviceFromSnapshot(MergeSnapshots(snaps), &SnapshotOptions{
+		Name:       name,
+		ID:         &virtual,
+		DropEvents: []input.EventCode{input.EV_FF},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	dev.SetHandler(ledForwarder{sources: sources, leds: leds})
+
+	err = dev.Create()
+	if err != nil {
+		return nil, errors.Join(err, dev.Close())
+	}
+
+	return dev, nil
+}
+
+// MergeSnapshots returns a snapshot with the union of the capabilities of
+// snaps, taking the name, identifier and repeat settings of the first
+// that has them. Absolute axes present in several snapshots get the
+// widest range and the parameters of the first. Event types none of snaps
+// has stay absent.
+func MergeSnapshots(snaps []*evdev.Snapshot) *evdev.Snapshot {
+	var (
+		merged *evdev.Snapshot
+		snap   *evdev.Snapshot
+	)
+
+	merged = new(evdev.Snapshot)
+
+	for _, snap = range snaps {
+		if merged.Name == "" {
+			merged.Name, merged.ID = snap.Name, snap.ID
+		}
+
+		if merged.Repeat == nil && snap.Repeat != nil {
+			merged.Repeat = maps.Clone(snap.Repeat)
+		}
+
+		merged.Key = unionMap(merged.Key, snap.Key)
+		merged.Switch = unionMap(merged.Switch, snap.Switch)
+		merged.LED = unionMap(merged.LED, snap.LED)
+		merged.Sound = unionMap(merged.Sound, snap.Sound)
+		merged.Relative = unionSlice(merged.Relative, snap.Relative)
+		merged.Misc = unionSlice(merged.Misc, snap.Misc)
+		merged.ForceFeedback = unionSlice(merged.ForceFeedback, snap.ForceFeedback)
+		merged.Properties = unionSlice(merged.Properties, snap.Properties)
+		merged.Absolute = mergeAbsolute(merged.Absolute, snap.Absolute)
+	}
+
+	return merged
+}
+
+// Feed passes an event read from source on. Events are held back until
+// the source's [input.SYN_REPORT], then emitted as one frame without the
+// key events that do not change the merged key state. After a
+// [input.SYN_DROPPED] the source's events up to and including its next
+// [input.SYN_REPORT] are discarded; call [Merger.Resync] after it.
+func (merger *Merger) Feed(source int, event input.Event) error {
+	var frame []input.Event
+
+	merger.mu.Lock()
+	defer merger.mu.Unlock()
+
+	if event.Type != input.EV_SYN {
+		if !merger.dropped[source] {
+			merger.frames[source] = append(merger.frames[source], event)
+		}
+
+		return nil
+	}
+
+	switch input.SyncCode(event.Code) {
+	case input.SYN_DROPPED:
+		merger.frames[source] = nil
+		merger.dropped[source] = true
+
+		return nil
+	case input.SYN_REPORT:
+		frame = merger.frames[source]
+		merger.frames[source] = nil
+
+		if merger.dropped[source] {
+			merger.dropped[source] = false
+
+			return nil
+		}
+
+		return merger.emit(source, frame)
+	default:
+		merger.frames[source] = append(merger.frames[source], event)
+
+		return nil
+	}
+}
+
+// Resync brings the merged key state in line with the keys pressed on
+// source, such as those reported by [evdev.Device.EnabledKeycodes] after
+// events were dropped, and emits the presses and releases this causes.
+func (merger *Merger) Resync(source int, pressed []input.KeyCode) error {
+	var (
+		frame   []input.Event
+		key     input.KeyCode
+		holders map[int]bool
+	)
+
+	merger.mu.Lock()
+	defer merger.mu.Unlock()
+
+	for _, key = range slices.Sorted(maps.Keys(merger.held)) {
+		holders = merger.held[key]
+		if holders[source] && !slices.Contains(pressed, key) {
+			frame = append(frame, KeyEvent(key, 0))
+		}
+	}
+
+	for _, key = range pressed {
+		if !merger.held[key][source] {
+			frame = append(frame, KeyEvent(key, 1))
+		}
+	}
+
+	return merger.emit(source, frame)
+}
+
+// Run reads events from sources and feeds them to the [Merger], source i
+// being sources[i], until ctx is cancelled or a source fails. After a
+// source drops events it is resynchronised with its pressed keys. It
+// starts [evdev.Device.ReadEvents] on every source, so the sources must
+// not be read elsewhere; grab them with [evdev.Device.Grab] to hide their
+// own events from applications. Run returns ctx's error on cancellation,
+// or an error wrapping the source's read error; a source reaching end of
+// file is reported as [io.EOF].
+func (merger *Merger) Run(ctx context.Context, sources ...*evdev.Device) error {
+	var (
+		cancel  context.CancelFunc
+		events  <-chan evdevmux.Event
+		errs    <-chan error
+		merged  evdevmux.Event
+		resync  []bool
+		pressed []input.KeyCode
+		err     error
+	)
+
+	ctx, cancel = context.WithCancel(ctx)
+	defer cancel()
+
+	events, errs = evdevmux.Read(ctx, sources, "failed to read merged events")
+	resync = make([]bool, len(sources))
+
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case err = <-errs:
+			return err
+		case merged = <-events:
+		}
+
+		err = merger.Feed(merged.Source, merged.Event)
+		if err != nil {
+			return err
+		}
+
+		switch {
+		case merged.Event.Type != input.EV_SYN:
+			continue
+		case input.SyncCode(merged.Event.Code) == input.SYN_DROPPED:
+			resync[merged.Source] = true
+
+			continue
+		case input.SyncCode(merged.Event.Code) != input.SYN_REPORT || !resync[merged.Source]:
+			continue
+		}
+
+		resync[merged.Source] = false
+
+		pressed, err = sources[merged.Source].EnabledKeycodes()
+		if err != nil {
+			return fmt.Errorf("failed to resync merged device: %w", err)
+		}
+
+		err = merger.Resync(merged.Source, pressed)
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// LED sets the LED on every source that has it.
+func (forwarder ledForwarder) LED(code input.LEDCode, on bool) {
+	var (
+		idx int
+		ok  bool
+	)
+
+	for idx = range forwarder.sources {
+		_, ok = forwarder.leds[idx][code]
+		if ok {
+			_ = forwarder.sources[idx].SetLED(code, on)
+		}
+	}
+}
+
+// emit sends frame from source, without the key events that do not
+// change the merged key state, followed by a [input.SYN_REPORT]. Nothing
+// is sent if no event is left.
+func (merger *Merger) emit(source int, frame []input.Event) error {
+	var (
+		out   []input.Event
+		event input.Event
+		err   error
+	)
+
+	for _, event = range frame {
+		if event.Type == input.EV_KEY && !merger.key(source, event) {
+			continue
+		}
+
+		out = append(out, event)
+	}
+
+	if len(out) == 0 {
+		return nil
+	}
+
+	err = merger.emitter.EmitBatch(
+		append(out, SyncEvent()),
+	)
+	if err != nil {
+		return fmt.Errorf("failed to emit merged frame: %w", err)
+	}
+
+	return nil
+}
+
+// key records a key event from source and reports whether it changes the
+// merged key state: the first press of a key on any source, or the last
+// release.
+func (merger *Merger) key(source int, event input.Event) bool {
+	var (
+		key     input.KeyCode
+		holders map[int]bool
+	)
+
+	key = input.KeyCode(event.Code)
+	holders = merger.held[key]
+
+	switch event.Value {
+	case 0:
+		if !holders[source] {
+			return false
+		}
+
+		delete(holders, source)
+
+		if len(holders) != 0 {
+			return false
+		}
+
+		delete(merger.held, key)
+
+		return true
+	case 1:
+		if holders[source] {
+			return false
+		}
+
+		if holders == nil {
+			holders = make(map[int]bool)
+			merger.held[key] = holders
+		}
+
+		holders[source] = true
+
+		return len(holders) == 1
+	default:
+		return false
+	}
+}
+
+func unionMap[K comparable, V any](dst, src map[K]V) map[K]V {
+	var (
+		key  K
+		zero V
+	)
+
+	if src == nil {
+		return dst
+	}
+
+	if dst == nil {
+		dst = make(map[K]V, len(src))
+	}
+
+	for key = range src {
+		dst[key] = zero
+	}
+
+	return dst
+}
+
+func unionSlice[T input.Code](dst, src []T) []T {
+	if src == nil {
+		return dst
+	}
+
+	dst = append(dst, src...)
+	slices.Sort(dst)
+
+	return slices.Compact(dst)
+}
+
+func mergeAbsolute(
+	dst, src map[input.AbsoluteCode]input.AbsInfo,
+) map[input.AbsoluteCode]input.AbsInfo {
+	var (
+		code    input.AbsoluteCode
+		absInfo input.AbsInfo
+		old     input.AbsInfo
+		ok      bool
+	)
+
+	if src == nil {
+		return dst
+	}
+
+	if dst == nil {
+		dst = make(map[input.AbsoluteCode]input.AbsInfo, len(src))
+	}
+
+	for code, absInfo = range src {
+		old, ok = dst[code]
+		if ok {
+			old.Minimum = min(old.Minimum, absInfo.Minimum)
+			old.Maximum = max(old.Maximum, absInfo.Maximum)
+			absInfo = old
+		}
+
+		dst[code] = absInfo
+	}
+
+	return dst
+}
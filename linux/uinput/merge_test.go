@@ -0,0 +1,134 @@
+package uinput_test
+
+import (
+	"errors"
+	"slices"
+	"testing"
+
+	"github.com/andrieee44/gopkg/linux/evdev"
+	"github.com/andrieee44/gopkg/linux/uapi/input"
+	"github.com/andrieee44/gopkg/linux/uinput"
+	"github.com/andrieee44/gopkg/linux/uinput/uinputtest"
+)
+
+func key(code input.KeyCode, value int32) input.Event {
+	return input.Event{Type: input.EV_KEY, Code: uint16(code), Value: value}
+}
+
+func syn(code input.SyncCode) input.Event {
+	return input.Event{Type: input.EV_SYN, Code: uint16(code)}
+}
+
+func TestMerger(t *testing.T) {
+	type feed struct {
+		source int
+		event  input.Event
+	}
+
+	var (
+		rec    *uinputtest.Recorder
+		merger *uinput.Merger
+		feeds  []feed
+		step   feed
+		exp    [][]string
+		frames [][]string
+		idx    int
+		errs   []error
+	)
+
+	t.Parallel()
+
+	rec = new(uinputtest.Recorder)
+	merger = uinput.NewMerger(rec, 2)
+
+	feeds = []feed{
+		{0, key(input.KEY_LEFTSHIFT, 1)},
+		{1, key(input.KEY_LEFTSHIFT, 1)},
+		{0, key(input.KEY_A, 1)},
+		{1, syn(input.SYN_REPORT)},
+		{0, syn(input.SYN_REPORT)},
+		{0, key(input.KEY_A, 2)},
+		{0, syn(input.SYN_REPORT)},
+		{0, key(input.KEY_LEFTSHIFT, 0)},
+		{0, syn(input.SYN_REPORT)},
+		{1, input.Event{Type: input.EV_REL, Code: uint16(input.REL_X), Value: 3}},
+		{1, key(input.KEY_LEFTSHIFT, 0)},
+		{1, syn(input.SYN_REPORT)},
+		{1, key(input.KEY_B, 1)},
+		{1, syn(input.SYN_DROPPED)},
+		{1, key(input.KEY_C, 1)},
+		{1, syn(input.SYN_REPORT)},
+	}
+
+	for _, step = range feeds {
+		errs = append(errs, merger.Feed(step.source, step.event))
+	}
+
+	errs = append(errs, merger.Resync(0, nil), merger.Resync(1, []input.KeyCode{input.KEY_C}))
+
+	if errors.Join(errs...) != nil {
+		t.Fatal(errors.Join(errs...))
+	}
+
+	exp = [][]string{
+		{"KEY_LEFTSHIFT 1"},
+		{"KEY_A 1"},
+		{"REL_X 3", "KEY_LEFTSHIFT 0"},
+		{"KEY_A 0"},
+		{"KEY_C 1"},
+	}
+
+	frames = rec.Frames()
+	if len(frames) != len(exp) {
+		t.Fatalf("frames: got: %v, exp: %v", frames, exp)
+	}
+
+	for idx = range exp {
+		if !slices.Equal(frames[idx], exp[idx]) {
+			t.Errorf("frame %d: got: %v, exp: %v", idx, frames[idx], exp[idx])
+		}
+	}
+}
+
+func TestMergeSnapshots(t *testing.T) {
+	var (
+		left, right, merged *evdev.Snapshot
+		expRelative         []input.RelativeCode
+		expAbs              input.AbsInfo
+	)
+
+	t.Parallel()
+
+	left = &evdev.Snapshot{
+		Name:     "left",
+		Key:      map[input.KeyCode]bool{input.KEY_A: true},
+		Repeat:   map[input.RepeatCode]uint32{input.REP_DELAY: 250, input.REP_PERIOD: 33},
+		Absolute: map[input.AbsoluteCode]input.AbsInfo{input.ABS_X: {Minimum: 0, Maximum: 100}},
+	}
+	right = &evdev.Snapshot{
+		Name:     "right",
+		Key:      map[input.KeyCode]bool{input.KEY_B: false},
+		Relative: []input.RelativeCode{input.REL_Y, input.REL_X},
+		Absolute: map[input.AbsoluteCode]input.AbsInfo{input.ABS_X: {Minimum: -10, Maximum: 50}},
+	}
+
+	merged = uinput.MergeSnapshots([]*evdev.Snapshot{left, right})
+
+	if merged.Name != "left" || len(merged.Key) != 2 || merged.Repeat[input.REP_DELAY] != 250 {
+		t.Errorf("got: %+v", merged)
+	}
+
+	expRelative = []input.RelativeCode{input.REL_X, input.REL_Y}
+	if !slices.Equal(merged.Relative, expRelative) {
+		t.Errorf("relative: got: %v, exp: %v", merged.Relative, expRelative)
+	}
+
+	expAbs = input.AbsInfo{Minimum: -10, Maximum: 100}
+	if merged.Absolute[input.ABS_X] != expAbs {
+		t.Errorf("ABS_X: got: %+v, exp: %+v", merged.Absolute[input.ABS_X], expAbs)
+	}
+
+	if merged.Switch != nil || merged.Misc != nil {
+		t.Errorf("absent types: got: %v, %v, exp: nil", merged.Switch, merged.Misc)
+	}
+}
@@ -0,0 +1,297 @@
+package uinput
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/andrieee44/gopkg/linux/evdev"
+	"github.com/andrieee44/gopkg/linux/uapi/input"
+)
+
+// DefaultRepeatRate is the key repeat rate used by [NewRepeater] when
+// [RepeatOptions].Rate is nil. It is the kernel's default.
+var DefaultRepeatRate RepeatRate = RepeatRate{
+	Delay:  250 * time.Millisecond,
+	Period: 33 * time.Millisecond,
+}
+
+// DefaultRepeatExclude lists the keys [NewRepeater] never repeats when
+// [RepeatOptions].Exclude is nil: the modifiers and lock keys.
+var DefaultRepeatExclude []input.KeyCode = []input.KeyCode{
+	input.KEY_LEFTCTRL,
+	input.KEY_RIGHTCTRL,
+	input.KEY_LEFTSHIFT,
+	input.KEY_RIGHTSHIFT,
+	input.KEY_LEFTALT,
+	input.KEY_RIGHTALT,
+	input.KEY_LEFTMETA,
+	input.KEY_RIGHTMETA,
+	input.KEY_CAPSLOCK,
+	input.KEY_NUMLOCK,
+	input.KEY_SCROLLLOCK,
+}
+
+// RepeatRate is how a held key repeats.
+type RepeatRate struct {
+	// Delay is the time a key is held before it starts repeating.
+	Delay time.Duration
+
+	// Period is the time between repeats. A Period that is not positive
+	// turns repeating off, as it does on a device with repeat turned off.
+	Period time.Duration
+}
+
+// RepeatOptions configures a [Repeater].
+type RepeatOptions struct {
+	// Rate is the repeat rate of every key not in Keys, such as one from
+	// [DeviceRepeatRate]. It defaults to [DefaultRepeatRate] if nil.
+	Rate *RepeatRate
+
+	// Keys overrides Rate for individual keys.
+	Keys map[input.KeyCode]RepeatRate
+
+	// Exclude lists the keys that never repeat. It defaults to
+	// [DefaultRepeatExclude]; set it to an empty slice to repeat every
+	// key.
+	Exclude []input.KeyCode
+}
+
+// Repeater generates key repeats in userspace for the events passed
+// through it, for virtual devices whose kernel autorepeat does not match
+// the device they stand in for, such as the output of a remapper that
+// grabs a keyboard. It emits a repeat, an [input.EV_KEY] event with value
+// 2, and an [input.SYN_REPORT] every period once a key has been held for
+// the delay. Like the kernel, it repeats only the last key pressed:
+// pressing another key that is not excluded takes over, with a new delay,
+// and releasing the repeating key stops repeating. Excluded keys neither
+// repeat nor interrupt the repeating key. A frame releasing the repeating
+// key and pressing another, as a remapper does when its layer changes
+// under a held key, moves the repeat to the new key without a new delay.
+// Repeats in the events passed through are dropped. Use it with a device
+// without [input.EV_REP], which the kernel would repeat itself. It is safe
+// for concurrent use.
+type Repeater struct {
+	emitter   Emitter
+	rate      RepeatRate
+	keys      map[input.KeyCode]RepeatRate
+	exclude   []input.KeyCode
+	key       input.KeyCode
+	active    bool
+	repeating bool
+	timer     *time.Timer
+	gen       uint64
+	err       error
+	closed    bool
+	mu        sync.Mutex
+}
+
+// NewRepeater returns a [Repeater] emitting to emitter.
+func NewRepeater(emitter Emitter, opts RepeatOptions) *Repeater {
+	var rate RepeatRate
+
+	rate = DefaultRepeatRate
+	if opts.Rate != nil {
+		rate = *opts.Rate
+	}
+
+	if opts.Exclude == nil {
+		opts.Exclude = DefaultRepeatExclude
+	}
+
+	return &Repeater{
+		emitter: emitter,
+		rate:    rate,
+		keys:    maps.Clone(opts.Keys),
+		exclude: opts.Exclude,
+	}
+}
+
+// DeviceRepeatRate returns the repeat rate of dev, as reported by
+// [evdev.Device.Repeat], to use as [RepeatOptions].Rate. Like the kernel,
+// it treats a zero delay or period as repeat turned off, and returns a
+// zero Period then.
+func DeviceRepeatRate(dev *evdev.Device) (RepeatRate, error) {
+	var (
+		settings [2]uint32
+		err      error
+	)
+
+	settings, err = dev.Repeat()
+	if err != nil {
+		return RepeatRate{}, err
+	}
+
+	if settings[0] == 0 {
+		settings[1] = 0
+	}
+
+	return RepeatRate{
+		Delay:  time.Duration(settings[0]) * time.Millisecond,
+		Period: time.Duration(settings[1]) * time.Millisecond,
+	}, nil
+}
+
+// EmitBatch passes events on without their key repeats and starts or
+// stops repeating for the keys they press and release. If emitting a
+// repeat failed since the last call, repeating stops and the error is
+// returned instead.
+func (rep *Repeater) EmitBatch(events []input.Event) error {
+	var (
+		out   []input.Event
+		event input.Event
+		carry bool
+		err   error
+	)
+
+	rep.mu.Lock()
+	defer rep.mu.Unlock()
+
+	if rep.err != nil {
+		err, rep.err = rep.err, nil
+
+		return err
+	}
+
+	for _, event = range events {
+		if event.Type != input.EV_KEY {
+			out = append(out, event)
+
+			continue
+		}
+
+		switch event.Value {
+		case 0:
+			if rep.active && input.KeyCode(event.Code) == rep.key {
+				carry = rep.repeating
+				rep.stop()
+			}
+		case 1:
+			if !rep.closed && !slices.Contains(rep.exclude, input.KeyCode(event.Code)) {
+				rep.start(input.KeyCode(event.Code), carry)
+			}
+		default:
+			continue
+		}
+
+		out = append(out, event)
+	}
+
+	if len(out) == 0 {
+		return nil
+	}
+
+	err = rep.emitter.EmitBatch(out)
+	if err != nil {
+		rep.stop()
+
+		return err
+	}
+
+	return nil
+}
+
+// Repeating returns the key being held for repeating, and whether there
+// is one.
+func (rep *Repeater) Repeating() (input.KeyCode, bool) {
+	rep.mu.Lock()
+	defer rep.mu.Unlock()
+
+	return rep.key, rep.active
+}
+
+// Close stops the current repeat. Events passed through afterwards are
+// still emitted but start no repeat.
+func (rep *Repeater) Close() {
+	rep.mu.Lock()
+	defer rep.mu.Unlock()
+
+	rep.stop()
+	rep.closed = true
+}
+
+// start makes key the repeating key, repeating after its delay, or after
+// its period if carry is set. A key whose period is not positive only
+// stops the repeating key.
+func (rep *Repeater) start(key input.KeyCode, carry bool) {
+	var (
+		rate RepeatRate
+		wait time.Duration
+	)
+
+	rep.stop()
+
+	rate = rep.rateOf(key)
+	if rate.Period <= 0 {
+		return
+	}
+
+	rep.key, rep.active, rep.repeating = key, true, carry
+	wait = rate.Delay
+
+	if carry {
+		wait = rate.Period
+	}
+
+	rep.schedule(wait)
+}
+
+// stop stops repeating and invalidates pending timers.
+func (rep *Repeater) stop() {
+	if rep.timer != nil {
+		rep.timer.Stop()
+		rep.timer = nil
+	}
+
+	rep.gen++
+	rep.active, rep.repeating = false, false
+}
+
+func (rep *Repeater) schedule(wait time.Duration) {
+	var gen uint64
+
+	gen = rep.gen
+	rep.timer = time.AfterFunc(wait, func() {
+		rep.fire(gen)
+	})
+}
+
+// fire emits a repeat of the repeating key if the timer of generation gen
+// is still current, and schedules the next one.
+func (rep *Repeater) fire(gen uint64) {
+	var err error
+
+	rep.mu.Lock()
+	defer rep.mu.Unlock()
+
+	if gen != rep.gen || !rep.active {
+		return
+	}
+
+	err = rep.emitter.EmitBatch([]input.Event{KeyEvent(rep.key, 2), SyncEvent()})
+	if err != nil {
+		rep.err = fmt.Errorf("failed to emit key repeat: %w", err)
+		rep.stop()
+
+		return
+	}
+
+	rep.repeating = true
+	rep.schedule(rep.rateOf(rep.key).Period)
+}
+
+func (rep *Repeater) rateOf(key input.KeyCode) RepeatRate {
+	var (
+		rate RepeatRate
+		ok   bool
+	)
+
+	rate, ok = rep.keys[key]
+	if ok {
+		return rate
+	}
+
+	return rep.rate
+}
@@ -0,0 +1,130 @@
+package uinput_test
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/andrieee44/gopkg/linux/uapi/input"
+	"github.com/andrieee44/gopkg/linux/uinput"
+	"github.com/andrieee44/gopkg/linux/uinput/uinputtest"
+)
+
+func TestRepeater(t *testing.T) {
+	var (
+		rec       *uinputtest.Recorder
+		rep       *uinput.Repeater
+		frames    [][]input.Event
+		events    []input.Event
+		frame     []string
+		repeats   map[string]int
+		repeating input.KeyCode
+		ok        bool
+		released  bool
+		err       error
+	)
+
+	t.Parallel()
+
+	rec = new(uinputtest.Recorder)
+	rep = uinput.NewRepeater(rec, uinput.RepeatOptions{
+		Rate: &uinput.RepeatRate{Delay: 30 * time.Millisecond, Period: 10 * time.Millisecond},
+		Keys: map[input.KeyCode]uinput.RepeatRate{
+			input.KEY_B: {Delay: time.Hour, Period: 10 * time.Millisecond},
+		},
+	})
+
+	frames = [][]input.Event{
+		{key(input.KEY_LEFTSHIFT, 1), syn(input.SYN_REPORT)},
+		{key(input.KEY_A, 1), key(input.KEY_A, 2), syn(input.SYN_REPORT)},
+		{key(input.KEY_A, 0), key(input.KEY_B, 1), syn(input.SYN_REPORT)},
+	}
+
+	for _, events = range frames {
+		err = rep.EmitBatch(events)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		time.Sleep(80 * time.Millisecond)
+	}
+
+	repeating, ok = rep.Repeating()
+	if repeating != input.KEY_B || !ok {
+		t.Errorf("repeating: got: %s %t, exp: %s true", repeating, ok, input.KEY_B)
+	}
+
+	rep.Close()
+
+	repeats = make(map[string]int)
+
+	for _, frame = range rec.Frames() {
+		if len(frame) == 2 && frame[0] == "KEY_A 0" {
+			released = true
+		}
+
+		if len(frame) != 1 || !strings.HasSuffix(frame[0], " 2") {
+			continue
+		}
+
+		if released && frame[0] == "KEY_A 2" {
+			t.Errorf("KEY_A repeated after release: %v", rec.Frames())
+		}
+
+		repeats[frame[0]]++
+	}
+
+	if repeats["KEY_LEFTSHIFT 2"] != 0 || repeats["KEY_A 2"] < 2 || repeats["KEY_B 2"] < 2 {
+		t.Errorf("repeats: got: %v, frames: %v", repeats, rec.Frames())
+	}
+}
+
+func TestRepeaterDisabled(t *testing.T) {
+	var (
+		rec     *uinputtest.Recorder
+		rep     *uinput.Repeater
+		code    input.KeyCode
+		frame   []string
+		repeats map[string]int
+		ok      bool
+		err     error
+	)
+
+	t.Parallel()
+
+	rec = new(uinputtest.Recorder)
+	rep = uinput.NewRepeater(rec, uinput.RepeatOptions{
+		Rate: &uinput.RepeatRate{},
+		Keys: map[input.KeyCode]uinput.RepeatRate{
+			input.KEY_B: {Delay: 10 * time.Millisecond, Period: 10 * time.Millisecond},
+		},
+	})
+
+	for _, code = range []input.KeyCode{input.KEY_B, input.KEY_A} {
+		err = rep.EmitBatch([]input.Event{key(code, 1), syn(input.SYN_REPORT)})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		time.Sleep(80 * time.Millisecond)
+	}
+
+	_, ok = rep.Repeating()
+	if ok {
+		t.Errorf("repeating: got: %t, exp: false", ok)
+	}
+
+	rep.Close()
+
+	repeats = make(map[string]int)
+
+	for _, frame = range rec.Frames() {
+		if len(frame) == 1 && strings.HasSuffix(frame[0], " 2") {
+			repeats[frame[0]]++
+		}
+	}
+
+	if repeats["KEY_A 2"] != 0 || repeats["KEY_B 2"] < 2 {
+		t.Errorf("repeats: got: %v, frames: %v", repeats, rec.Frames())
+	}
+}
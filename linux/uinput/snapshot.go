@@ -0,0 +1,208 @@
+package uinput
+
+import (
+	"errors"
+	"maps"
+	"slices"
+
+	"github.com/andrieee44/gopkg/linux/evdev"
+	"github.com/andrieee44/gopkg/linux/inputerr"
+	"github.com/andrieee44/gopkg/linux/uapi/input"
+	"github.com/andrieee44/gopkg/linux/uapi/uinput"
+)
+
+// SnapshotOptions adjusts the device built by [NewDeviceFromSnapshot]. The
+// zero value clones the snapshot unchanged.
+type SnapshotOptions struct {
+	// Name replaces the snapshot's name if not empty.
+	Name string
+
+	// ID replaces the snapshot's identifier if not nil.
+	ID *input.ID
+
+	// DropEvents lists event types, such as [input.EV_FF] or
+	// [input.EV_REP], left out together with all their codes.
+	DropEvents []input.EventCode
+
+	// Drop, if set, is called for each capability code of a kept event
+	// type, such as a key for [input.EV_KEY] or an axis for
+	// [input.EV_ABS]. Returning true leaves the code out.
+	Drop func(event input.EventCode, code input.Coder) bool
+
+	// DropProperties leaves out the snapshot's input properties.
+	DropProperties bool
+}
+
+// NewDeviceFromSnapshot returns a new [Device], opened like [NewDevice],
+// that presents itself like the device snap was taken from: same
+// identifier, name, physical location, properties, capabilities,
+// absolute axis parameters, key repeat settings and force feedback
+// effects, adjusted by opts, which may be nil. The unique identifier is
+// copied on kernels that allow it, see [Device.SetUniq]. This is what a
+// remapper that grabs a device needs to stand in for it.
+//
+// The [Device] is configured but not yet created. Repeat settings are
+// applied by [Device.Create]. If force feedback is kept the device stores
+// snap.FFEffects effects; call [Device.SetFFHandler] with that value
+// before [Device.Create] to store and play them.
+func NewDeviceFromSnapshot(snap *evdev.Snapshot, opts *SnapshotOptions) (*Device, error) {
+	var (
+		dev  *Device
+		id   input.ID
+		name string
+		err  error
+	)
+
+	if opts == nil {
+		opts = new(SnapshotOptions)
+	}
+
+	id, name = snap.ID, snap.Name
+
+	if opts.ID != nil {
+		id = *opts.ID
+	}
+
+	if opts.Name != "" {
+		name = opts.Name
+	}
+
+	dev, err = NewDevice(id, name)
+	if err != nil {
+		return nil, err
+	}
+
+	err = dev.setSnapshot(snap, opts)
+	if err != nil {
+		_ = dev.file.Close()
+
+		return nil, err
+	}
+
+	return dev, nil
+}
+
+func (dev *Device) setSnapshot(snap *evdev.Snapshot, opts *SnapshotOptions) error {
+	var (
+		absCodes []input.AbsoluteCode
+		absInfos []uinput.AbsSetup
+		code     input.AbsoluteCode
+		steps    []func() error
+		step     func() error
+		err      error
+	)
+
+	absCodes = keep(opts, input.EV_ABS, sortedKeys(snap.Absolute))
+	absInfos = make([]uinput.AbsSetup, 0, len(absCodes))
+
+	for _, code = range absCodes {
+		absInfos = append(absInfos, uinput.AbsSetup{Code: code, AbsInfo: snap.Absolute[code]})
+	}
+
+	steps = []func() error{
+		func() error { return dev.SetEvents(snapshotEvents(snap, opts)) },
+		func() error { return dev.SetKeys(keep(opts, input.EV_KEY, sortedKeys(snap.Key))) },
+		func() error { return dev.SetRelatives(keep(opts, input.EV_REL, snap.Relative)) },
+		func() error { return dev.SetAbsCodes(absCodes) },
+		func() error { return dev.SetAbsInfos(absInfos) },
+		func() error { return dev.SetMiscs(keep(opts, input.EV_MSC, snap.Misc)) },
+		func() error { return dev.SetSwitches(keep(opts, input.EV_SW, sortedKeys(snap.Switch))) },
+		func() error { return dev.SetLEDs(keep(opts, input.EV_LED, sortedKeys(snap.LED))) },
+		func() error { return dev.SetSounds(keep(opts, input.EV_SND, sortedKeys(snap.Sound))) },
+		func() error { return dev.SetForceFeedbacks(keep(opts, input.EV_FF, snap.ForceFeedback)) },
+	}
+
+	if !opts.DropProperties {
+		steps = append(steps, func() error { return dev.SetProps(snap.Properties) })
+	}
+
+	if snap.Phys != "" {
+		steps = append(steps, func() error { return dev.SetPhys(snap.Phys) })
+	}
+
+	if snap.Uniq != "" {
+		steps = append(steps, func() error { return dev.setUniqIfSupported(snap.Uniq) })
+	}
+
+	for _, step = range steps {
+		err = step()
+		if err != nil {
+			return err
+		}
+	}
+
+	if snap.FFEffects > 0 && !slices.Contains(opts.DropEvents, input.EV_FF) {
+		dev.setup.FFEffectsMax = uint32(snap.FFEffects)
+	}
+
+	if snap.Repeat != nil && !slices.Contains(opts.DropEvents, input.EV_REP) {
+		dev.repeat = &[2]uint32{snap.Repeat[input.REP_DELAY], snap.Repeat[input.REP_PERIOD]}
+	}
+
+	return nil
+}
+
+// snapshotEvents returns the event types snap reports, apart from those
+// uinput cannot create and those dropped by opts.
+func snapshotEvents(snap *evdev.Snapshot, opts *SnapshotOptions) []input.EventCode {
+	var (
+		present map[input.EventCode]bool
+		events  []input.EventCode
+		event   input.EventCode
+	)
+
+	present = map[input.EventCode]bool{
+		input.EV_SYN: true,
+		input.EV_KEY: snap.Key != nil,
+		input.EV_REL: snap.Relative != nil,
+		input.EV_ABS: snap.Absolute != nil,
+		input.EV_MSC: snap.Misc != nil,
+		input.EV_SW:  snap.Switch != nil,
+		input.EV_LED: snap.LED != nil,
+		input.EV_SND: snap.Sound != nil,
+		input.EV_REP: snap.Repeat != nil,
+		input.EV_FF:  snap.ForceFeedback != nil,
+	}
+
+	for event = range present {
+		if present[event] && !slices.Contains(opts.DropEvents, event) {
+			events = append(events, event)
+		}
+	}
+
+	slices.Sort(events)
+
+	return events
+}
+
+// keep returns the codes of event that opts does not drop.
+func keep[T input.Code](opts *SnapshotOptions, event input.EventCode, codes []T) []T {
+	if slices.Contains(opts.DropEvents, event) {
+		return nil
+	}
+
+	if opts.Drop == nil {
+		return codes
+	}
+
+	return slices.DeleteFunc(slices.Clone(codes), func(code T) bool {
+		return opts.Drop(event, input.Coder(code))
+	})
+}
+
+func sortedKeys[T input.Code, V any](codes map[T]V) []T {
+	return slices.Sorted(maps.Keys(codes))
+}
+
+// setUniqIfSupported sets the unique identifier of the device on kernels
+// that allow it, see [Device.SetUniq].
+func (dev *Device) setUniqIfSupported(uniq string) error {
+	var err error
+
+	err = dev.SetUniq(uniq)
+	if errors.Is(err, inputerr.ErrUnsupported) {
+		return nil
+	}
+
+	return err
+}
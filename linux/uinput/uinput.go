@@ -1,90 +1,156 @@
 // Package uinput provides a pure-Go interface to the Linux uinput
-// subsystem. It lets you create virtual input devices, emit events, and
-// handle force feedback (FF) upload and erase requests from the kernel.
+// subsystem. It lets you create virtual input devices, emit events,
+// store and play the force feedback (FF) effects applications send through
+// an [FFHandler], and receive the LED and sound events sent back through a
+// [Handler].
+//
+// Errors from opening /dev/uinput and from its ioctls are reported as an
+// [inputerr.OpError], classified by the [inputerr] sentinels.
 package uinput
 
 import (
+	"bytes"
 	"encoding/binary"
 	"errors"
 	"fmt"
-	"io"
+	"maps"
 	"os"
+	"slices"
+	"sync"
+	"syscall"
+	"time"
 
+	"github.com/andrieee44/gopkg/linux/inputerr"
 	"github.com/andrieee44/gopkg/linux/internal/ioctlwrap"
 	"github.com/andrieee44/gopkg/linux/uapi/input"
+	"github.com/andrieee44/gopkg/linux/uapi/ioctl"
 	"github.com/andrieee44/gopkg/linux/uapi/uinput"
+	"golang.org/x/sys/unix"
 )
 
-// FFUploadEvent represents a force feedback upload request from the kernel.
-// FF contains the [uinput.FFUpload] data to be stored, and Ret is a
-// one‑shot channel the handler must send the updated [uinput.FFUpload] back
-// on (with [uinput.FFUpload].Retval set).
-type FFUploadEvent struct {
-	FF  uinput.FFUpload
-	Ret chan<- uinput.FFUpload
-}
-
-// FFEraseEvent represents a force feedback erase request from the kernel.
-// [FF] contains the [uinput.FFErase] identifying the effect to remove, and
-// [Ret] is a one‑shot channel the handler must send the updated
-// [uinput.FFErase] back on (with [uinput.FFUpload].Retval set).
-type FFEraseEvent struct {
-	FF  uinput.FFErase
-	Ret chan<- uinput.FFErase
-}
-
 // Device represents a virtual input device created through uinput. Use
 // [NewDevice] to construct one, configure its capabilities with the Set*
 // methods, then call [Device.Create] to activate it. When finished, call
-// [Device.Destroy] to remove it from the system.
+// [Device.Close] to remove it from the system and release it.
 type Device struct {
-	setup      uinput.Setup
-	file       *os.File
-	uploadChan chan FFUploadEvent
-	eraseChan  chan FFEraseEvent
-	errChan    chan error
+	setup     uinput.Setup
+	absInfos  []uinput.AbsSetup
+	events    map[input.EventCode]bool
+	codes     map[input.EventCode]map[uint16]bool
+	scroll    [2]int32
+	scrollMu  sync.Mutex
+	repeat    *[2]uint32
+	file      *os.File
+	created   bool
+	handler   Handler
+	ffHandler FFHandler
+	ffTimeout time.Duration
+	playing   map[int16]int32
+	playingMu sync.Mutex
+	wake      int
+	done      chan struct{}
+	serveErr  error
 }
 
-// ErrNameTooLong is returned by [NewDevice] when the provided name exceeds
-// [uinput.UINPUT_MAX_NAME_SIZE] bytes including the null terminator.
-var ErrNameTooLong error = errors.New("name is too long")
+var (
+	// ErrNameTooLong is returned by [NewDevice] when the provided name
+	// exceeds [uinput.UINPUT_MAX_NAME_SIZE] bytes including the null
+	// terminator.
+	ErrNameTooLong error = errors.New("name is too long")
+
+	// ErrInvalidSetup is returned by [Device.Create] when the configured
+	// capabilities would be rejected by the kernel, such as key codes set
+	// without enabling [input.EV_KEY].
+	ErrInvalidSetup error = errors.New("invalid uinput device setup")
+)
+
+// DefaultPath is the uinput control device opened by [NewDevice].
+const DefaultPath string = "/dev/uinput"
+
+// DefaultFFTimeout is how long an [FFHandler] has to answer an upload or
+// erase request unless changed with [Device.SetFFTimeout].
+const DefaultFFTimeout time.Duration = time.Second
 
 // NewDevice returns a new [Device] with the given [input.ID] and name,
-// opening /dev/uinput for writing. The name must not exceed
+// opening [DefaultPath] for reading and writing. The name must not exceed
 // [uinput.UINPUT_MAX_NAME_SIZE] bytes including the null terminator,
 // otherwise [ErrNameTooLong] is returned. Before activating the [Device]
 // with [Device.Create], clients should configure its capabilities using
 // the provided Set* methods such as [Device.SetKeys] or [Device.SetAbsCodes].
 func NewDevice(id input.ID, name string) (*Device, error) {
+	return OpenDevice(DefaultPath, id, name)
+}
+
+// OpenDevice is like [NewDevice] but opens the uinput control device at
+// path, for systems that place it elsewhere, such as /dev/input/uinput.
+func OpenDevice(path string, id input.ID, name string) (*Device, error) {
+	var (
+		dev  *Device
+		file *os.File
+		err  error
+	)
+
+	dev, err = newDevice(id, name)
+	if err != nil {
+		return nil, err
+	}
+
+	file, err = os.OpenFile(path, os.O_RDWR, 0)
+	if err != nil {
+		return nil, inputerr.Wrap("failed to open uinput device", path, err)
+	}
+
+	dev.file = file
+
+	return dev, nil
+}
+
+// NewDeviceFromFile returns a new [Device] like [NewDevice], using an
+// already open uinput control device file, such as one passed in by a
+// privileged helper. The file must be open for reading and writing. The
+// [Device] takes ownership of file. NewDeviceFromFile checks that file
+// answers uinput requests; file is left open if it does not.
+func NewDeviceFromFile(file *os.File, id input.ID, name string) (*Device, error) {
 	var (
-		buf [uinput.UINPUT_MAX_NAME_SIZE]byte
 		dev *Device
 		err error
 	)
 
-	if len(name)+1 > uinput.UINPUT_MAX_NAME_SIZE {
-		return nil, fmt.Errorf(
-			"failed to allocate uinput device: name is %d bytes long, max is %d bytes including the null terminator: %w",
-			len(name),
-			uinput.UINPUT_MAX_NAME_SIZE,
-			ErrNameTooLong,
-		)
+	dev, err = newDevice(id, name)
+	if err != nil {
+		return nil, err
 	}
 
-	copy(buf[:], name)
+	err = checkFd(file.Fd(), file.Name())
+	if err != nil {
+		return nil, err
+	}
 
-	dev = &Device{
-		setup: uinput.Setup{
-			ID:   id,
-			Name: buf,
-		},
+	dev.file = file
+
+	return dev, nil
+}
+
+// NewDeviceFromFd is like [NewDeviceFromFile] for a raw file descriptor.
+// The fd is only taken over if it answers uinput requests.
+func NewDeviceFromFd(fd uintptr, fdName string, id input.ID, name string) (*Device, error) {
+	var (
+		dev *Device
+		err error
+	)
+
+	dev, err = newDevice(id, name)
+	if err != nil {
+		return nil, err
 	}
 
-	dev.file, err = os.OpenFile("/dev/uinput", os.O_WRONLY, 0)
+	err = checkFd(fd, fdName)
 	if err != nil {
 		return nil, err
 	}
 
+	dev.file = os.NewFile(fd, fdName)
+
 	return dev, nil
 }
 
@@ -95,20 +161,76 @@ func (dev *Device) Fd() uintptr {
 
 // Create activates the [Device], making it available for use by the system.
 // Call this after adding all desired capabilities with the Set* methods.
-// Returns an error if activation fails.
+//
+// Create first checks the configuration and returns [ErrInvalidSetup] for
+// one the kernel would reject, naming the problem. It then sends the
+// identifier, name and force feedback capacity with UI_DEV_SETUP and the
+// absolute axes with UI_ABS_SETUP before UI_DEV_CREATE. Kernels older
+// than 4.5 answer UI_DEV_SETUP with EINVAL; Create then falls back to
+// writing the legacy [uinput.UserDev] structure, which has no room for
+// axis resolutions. Key repeat settings copied by [NewDeviceFromSnapshot]
+// are applied once the device exists, and the event loop serving the
+// [FFHandler] and [Handler] starts; [Device.Close] stops it.
 func (dev *Device) Create() error {
-	return ioctlwrap.SetAny(
+	var err error
+
+	err = dev.validate()
+	if err != nil {
+		return err
+	}
+
+	err = ioctlwrap.SetAny(
 		dev.file,
-		uinput.UI_DEV_CREATE,
+		uinput.UI_DEV_SETUP,
 		&dev.setup,
+		"failed to set up uinput device",
+	)
+
+	switch {
+	case err == nil:
+		err = dev.setupAbs()
+	case errors.Is(err, syscall.EINVAL):
+		err = dev.setupLegacy()
+	}
+
+	if err != nil {
+		return err
+	}
+
+	err = ioctlwrap.Empty(
+		dev.file,
+		uinput.UI_DEV_CREATE,
 		"failed to create uinput device",
 	)
+	if err != nil {
+		return err
+	}
+
+	dev.created = true
+
+	err = dev.startServe()
+	if err != nil {
+		return err
+	}
+
+	if dev.repeat == nil {
+		return nil
+	}
+
+	return dev.writeEvents([]input.Event{
+		{Type: input.EV_REP, Code: uint16(input.REP_DELAY), Value: int32(dev.repeat[0])},
+		{Type: input.EV_REP, Code: uint16(input.REP_PERIOD), Value: int32(dev.repeat[1])},
+		{Type: input.EV_SYN, Code: uint16(input.SYN_REPORT)},
+	})
 }
 
 // Destroy deactivates the [Device] and removes it from the system. Once
 // destroyed, the device can no longer send events. Returns an error if
-// deactivation fails.
+// deactivation fails. The event loop and the uinput file are kept until
+// [Device.Close].
 func (dev *Device) Destroy() error {
+	dev.created = false
+
 	return ioctlwrap.Empty(
 		dev.file,
 		uinput.UI_DEV_DESTROY,
@@ -116,8 +238,27 @@ func (dev *Device) Destroy() error {
 	)
 }
 
-// Name returns the system‑assigned name of the [Device], typically something
-// like "eventN" where N is the event device number.
+// Close stops the event loop, destroys the [Device] if it was created
+// and closes its uinput file. It returns any error that stopped the event
+// loop early, such as a failed force feedback ioctl, together with those
+// of destroying and closing.
+func (dev *Device) Close() error {
+	var errs []error
+
+	errs = append(errs, dev.stopServe())
+
+	if dev.created {
+		errs = append(errs, dev.Destroy())
+	}
+
+	errs = append(errs, dev.file.Close())
+
+	return errors.Join(errs...)
+}
+
+// Name returns the system‑assigned name of the created [Device], its
+// sysname, such as "input23". Use [Device.EventDevice] to open its event
+// device node.
 func (dev *Device) Name() (string, error) {
 	return ioctlwrap.GetStr(
 		dev.file,
@@ -140,23 +281,26 @@ func (dev *Device) Version() (uint32, error) {
 
 // SetAbsInfos configures the absolute axis parameters for the [Device].
 // Each [uinput.AbsSetup] specifies the axis code and its value range,
-// fuzz, and flat settings.
+// fuzz, and flat settings, and enables the axis. The parameters are sent
+// to the kernel by [Device.Create]; a later entry for the same axis
+// replaces an earlier one.
 func (dev *Device) SetAbsInfos(absInfos []uinput.AbsSetup) error {
-	var (
-		absInfo uinput.AbsSetup
-		err     error
-	)
+	var absInfo uinput.AbsSetup
 
 	for _, absInfo = range absInfos {
-		err = ioctlwrap.SetAny(
-			dev.file,
-			uinput.UI_ABS_SETUP,
-			&absInfo,
-			"failed to set uinput device absolute infos",
-		)
-		if err != nil {
-			return err
+		if absInfo.Code > input.ABS_MAX {
+			return fmt.Errorf(
+				"failed to set uinput device absolute infos: axis %d: %w",
+				absInfo.Code,
+				ErrInvalidSetup,
+			)
 		}
+
+		dev.absInfos = slices.DeleteFunc(dev.absInfos, func(old uinput.AbsSetup) bool {
+			return old.Code == absInfo.Code
+		})
+		dev.absInfos = append(dev.absInfos, absInfo)
+		dev.enable(input.EV_ABS, []input.Coder{absInfo.Code})
 	}
 
 	return nil
@@ -166,9 +310,10 @@ func (dev *Device) SetAbsInfos(absInfos []uinput.AbsSetup) error {
 // [Device], allowing it to send events such as joystick positions or touch
 // coordinates.
 func (dev *Device) SetAbsCodes(codes []input.AbsoluteCode) error {
-	return setCodes(
-		dev.file,
+	return enableCodes(
+		dev,
 		uinput.UI_SET_ABSBIT,
+		input.EV_ABS,
 		codes,
 		"failed to set uinput device absolute codes",
 	)
@@ -177,20 +322,35 @@ func (dev *Device) SetAbsCodes(codes []input.AbsoluteCode) error {
 // SetEvents enables reporting of the given high‑level event types on the
 // [Device], such as key, relative, or absolute events.
 func (dev *Device) SetEvents(codes []input.EventCode) error {
-	return setCodes(
+	var (
+		code input.EventCode
+		err  error
+	)
+
+	err = setCodes(
 		dev.file,
 		uinput.UI_SET_EVBIT,
 		codes,
 		"failed to set uinput device event codes",
 	)
+	if err != nil {
+		return err
+	}
+
+	for _, code = range codes {
+		dev.events[code] = true
+	}
+
+	return nil
 }
 
 // SetForceFeedbacks enables the given force‑feedback effect codes on the
 // [Device], allowing it to send haptic feedback events.
 func (dev *Device) SetForceFeedbacks(codes []input.FFCode) error {
-	return setCodes(
-		dev.file,
+	return enableCodes(
+		dev,
 		uinput.UI_SET_FFBIT,
+		input.EV_FF,
 		codes,
 		"failed to set uinput device force feedback codes",
 	)
@@ -199,9 +359,10 @@ func (dev *Device) SetForceFeedbacks(codes []input.FFCode) error {
 // SetKeys enables reporting of the given key codes on the [Device], allowing
 // it to send key press and release events.
 func (dev *Device) SetKeys(codes []input.KeyCode) error {
-	return setCodes(
-		dev.file,
+	return enableCodes(
+		dev,
 		uinput.UI_SET_KEYBIT,
+		input.EV_KEY,
 		codes,
 		"failed to set uinput device key codes",
 	)
@@ -210,9 +371,10 @@ func (dev *Device) SetKeys(codes []input.KeyCode) error {
 // SetLEDs enables control of the given LED codes on the [Device], such as
 // keyboard lock lights or controller indicators.
 func (dev *Device) SetLEDs(codes []input.LEDCode) error {
-	return setCodes(
-		dev.file,
+	return enableCodes(
+		dev,
 		uinput.UI_SET_LEDBIT,
+		input.EV_LED,
 		codes,
 		"failed to set uinput device LED codes",
 	)
@@ -221,14 +383,50 @@ func (dev *Device) SetLEDs(codes []input.LEDCode) error {
 // SetMiscs enables reporting of the given miscellaneous codes on the
 // [Device], used for less common input events.
 func (dev *Device) SetMiscs(codes []input.MiscCode) error {
-	return setCodes(
-		dev.file,
+	return enableCodes(
+		dev,
 		uinput.UI_SET_MSCBIT,
+		input.EV_MSC,
 		codes,
 		"failed to set uinput device misc codes",
 	)
 }
 
+// SetPhys sets the physical location of the [Device], such as
+// "usb-0000:00:14.0-1/input0", reported by EVIOCGPHYS and matched by udev
+// rules and applications. It must be called before [Device.Create].
+func (dev *Device) SetPhys(phys string) error {
+	var buf []byte
+
+	buf = append([]byte(phys), 0)
+
+	return ioctlwrap.SetAny(
+		dev.file,
+		uinput.UI_SET_PHYS,
+		&buf[0],
+		"failed to set uinput device physical location",
+	)
+}
+
+// SetUniq sets the unique identifier of the [Device], such as a serial
+// number, reported by EVIOCGUNIQ. It must be called before
+// [Device.Create]. The UI_SET_UNIQ request it uses was reverted from
+// Linux before its 5.5 release and is only carried by some downstream
+// kernels; others reject it with an error matching
+// [inputerr.ErrUnsupported].
+func (dev *Device) SetUniq(uniq string) error {
+	var buf []byte
+
+	buf = append([]byte(uniq), 0)
+
+	return ioctlwrap.SetAny(
+		dev.file,
+		uiSetUniq,
+		&buf[0],
+		"failed to set uinput device unique id",
+	)
+}
+
 // SetProps enables the given input property codes on the [Device], describing
 // its capabilities (e.g., whether it has a direct touch surface).
 func (dev *Device) SetProps(codes []input.PropCode) error {
@@ -243,9 +441,10 @@ func (dev *Device) SetProps(codes []input.PropCode) error {
 // SetRelatives enables reporting of the given relative axis codes on the
 // [Device], such as mouse movement deltas.
 func (dev *Device) SetRelatives(codes []input.RelativeCode) error {
-	return setCodes(
-		dev.file,
+	return enableCodes(
+		dev,
 		uinput.UI_SET_RELBIT,
+		input.EV_REL,
 		codes,
 		"failed to set uinput device relative codes",
 	)
@@ -254,9 +453,10 @@ func (dev *Device) SetRelatives(codes []input.RelativeCode) error {
 // SetSounds enables playback of the given sound codes on the [Device], such
 // as clicks or tones.
 func (dev *Device) SetSounds(codes []input.SoundCode) error {
-	return setCodes(
-		dev.file,
+	return enableCodes(
+		dev,
 		uinput.UI_SET_SNDBIT,
+		input.EV_SND,
 		codes,
 		"failed to set uinput device sound codes",
 	)
@@ -265,43 +465,38 @@ func (dev *Device) SetSounds(codes []input.SoundCode) error {
 // SetSwitches enables reporting of the given switch codes on the [Device],
 // such as lid open/close or tablet mode switches.
 func (dev *Device) SetSwitches(codes []input.SwitchCode) error {
-	return setCodes(
-		dev.file,
+	return enableCodes(
+		dev,
 		uinput.UI_SET_SWBIT,
+		input.EV_SW,
 		codes,
 		"failed to set uinput device switch codes",
 	)
 }
 
-// SetFFEffectsMax sets the maximum number of force feedback effects the
-// device can store and starts its event loop. It returns three channels:
-// one for upload requests (FFUploadEvent), one for erase requests
-// (FFEraseEvent), and one for asynchronous errors. The caller should read
-// from the upload and erase channels, handle each request, and send the
-// updated struct back on the provided Ret channel. Errors from the device
-// are sent on the error channel.
-func (dev *Device) SetFFEffectsMax(
-	ffEffectsMax uint32,
-) (<-chan FFUploadEvent, <-chan FFEraseEvent, <-chan error) {
-	dev.setup.FFEffectsMax = ffEffectsMax
-	dev.uploadChan = make(chan FFUploadEvent)
-	dev.eraseChan = make(chan FFEraseEvent)
-	dev.errChan = make(chan error)
-
-	go dev.serve()
+// SetFFHandler sets the maximum number of force feedback effects the
+// device can store and the [FFHandler] storing and playing them. It must
+// be called before [Device.Create] on devices with [input.EV_FF].
+func (dev *Device) SetFFHandler(effectsMax uint32, handler FFHandler) {
+	dev.setup.FFEffectsMax = effectsMax
+	dev.ffHandler = handler
+}
 
-	return dev.uploadChan, dev.eraseChan, dev.errChan
+// SetFFTimeout sets how long the [FFHandler] has to answer an upload or
+// erase request before the kernel is told it timed out with
+// [syscall.ETIMEDOUT]. It defaults to [DefaultFFTimeout].
+func (dev *Device) SetFFTimeout(timeout time.Duration) {
+	dev.ffTimeout = timeout
 }
 
 func (dev *Device) uploadFF(value int32) error {
 	var (
-		ff      uinput.FFUpload
-		retChan chan uinput.FFUpload
-		err     error
+		ff          uinput.FFUpload
+		effect, old input.FFEffect
+		err         error
 	)
 
 	ff.RequestID = uint32(value)
-	retChan = make(chan uinput.FFUpload)
 
 	ff, err = ioctlwrap.GetAny(
 		dev.file,
@@ -313,35 +508,25 @@ func (dev *Device) uploadFF(value int32) error {
 		return err
 	}
 
-	dev.uploadChan <- FFUploadEvent{
-		FF:  ff,
-		Ret: retChan,
-	}
-
-	ff = <-retChan
+	effect, old = ff.Effect, ff.Old
+	ff.Retval = dev.callFF(func() error { return dev.ffHandler.Upload(effect, old) })
 
-	err = ioctlwrap.SetAny(
+	return ioctlwrap.SetAny(
 		dev.file,
 		uinput.UI_END_FF_UPLOAD,
 		&ff,
 		"failed to end uinput device force feedback upload",
 	)
-	if err != nil {
-		return err
-	}
-
-	return nil
 }
 
 func (dev *Device) eraseFF(value int32) error {
 	var (
-		ff      uinput.FFErase
-		retChan chan uinput.FFErase
-		err     error
+		ff  uinput.FFErase
+		id  int16
+		err error
 	)
 
 	ff.RequestID = uint32(value)
-	retChan = make(chan uinput.FFErase)
 
 	ff, err = ioctlwrap.GetAny(
 		dev.file,
@@ -353,12 +538,8 @@ func (dev *Device) eraseFF(value int32) error {
 		return err
 	}
 
-	dev.eraseChan <- FFEraseEvent{
-		FF:  ff,
-		Ret: retChan,
-	}
-
-	ff = <-retChan
+	id = int16(ff.EffectID)
+	ff.Retval = dev.callFF(func() error { return dev.ffHandler.Erase(id) })
 
 	err = ioctlwrap.SetAny(
 		dev.file,
@@ -370,50 +551,323 @@ func (dev *Device) eraseFF(value int32) error {
 		return err
 	}
 
+	if ff.Retval == 0 {
+		dev.setPlaying(id, 0)
+	}
+
 	return nil
 }
 
+// callFF runs fn, a request to the [FFHandler], and returns the value the
+// kernel is answered with: 0, the negated errno of fn's error, or
+// -ETIMEDOUT if fn does not return in time. A timed out fn keeps running
+// in the background.
+func (dev *Device) callFF(fn func() error) int32 {
+	var (
+		result  chan error
+		timeout time.Duration
+		timer   *time.Timer
+		err     error
+	)
+
+	timeout = dev.ffTimeout
+	if timeout <= 0 {
+		timeout = DefaultFFTimeout
+	}
+
+	result = make(chan error, 1)
+
+	go func() {
+		result <- fn()
+	}()
+
+	timer = time.NewTimer(timeout)
+	defer timer.Stop()
+
+	select {
+	case err = <-result:
+		return retval(err)
+	case <-timer.C:
+		return -int32(syscall.ETIMEDOUT)
+	}
+}
+
+// startServe starts the event loop, which waits for events on the uinput
+// file and for [Device.stopServe] to signal an eventfd.
+func (dev *Device) startServe() error {
+	var err error
+
+	dev.wake, err = unix.Eventfd(0, unix.EFD_CLOEXEC)
+	if err != nil {
+		return fmt.Errorf("failed to start uinput event loop: %w", err)
+	}
+
+	dev.done = make(chan struct{})
+
+	go dev.serve()
+
+	return nil
+}
+
+// stopServe stops the event loop if it is running and returns the error
+// that stopped it early, if any.
+func (dev *Device) stopServe() error {
+	var err error
+
+	if dev.done == nil {
+		return nil
+	}
+
+	_, err = unix.Write(dev.wake, binary.NativeEndian.AppendUint64(nil, 1))
+	if err != nil {
+		return fmt.Errorf("failed to stop uinput event loop: %w", err)
+	}
+
+	<-dev.done
+	dev.done = nil
+
+	return errors.Join(dev.serveErr, unix.Close(dev.wake))
+}
+
 func (dev *Device) serve() {
 	var (
-		event input.Event
-		err   error
+		fds []unix.PollFd
+		err error
 	)
 
+	defer close(dev.done)
+
+	fds = []unix.PollFd{
+		{Fd: int32(dev.file.Fd()), Events: unix.POLLIN},
+		{Fd: int32(dev.wake), Events: unix.POLLIN},
+	}
+
 	for {
-		err = binary.Read(dev.file, binary.NativeEndian, &event)
+		_, err = unix.Poll(fds, -1)
+		if errors.Is(err, unix.EINTR) {
+			continue
+		}
+
 		if err != nil {
-			if errors.Is(err, io.EOF) {
-				break
-			}
+			dev.serveErr = inputerr.Wrap("failed to poll uinput events", dev.file.Name(), err)
 
-			dev.errChan <- err
+			return
+		}
 
-			break
+		if fds[1].Revents != 0 {
+			return
 		}
 
-		if event.Type != uinput.EV_UINPUT {
+		if fds[0].Revents == 0 {
 			continue
 		}
 
-		err = nil
+		err = dev.serveEvent()
+		if err != nil {
+			dev.serveErr = err
 
-		switch event.Code {
-		case uinput.UI_FF_UPLOAD:
-			err = dev.uploadFF(event.Value)
-		case uinput.UI_FF_ERASE:
-			err = dev.eraseFF(event.Value)
+			return
 		}
+	}
+}
 
-		if err != nil {
-			dev.errChan <- err
+// serveEvent reads one event sent back by the kernel and answers or
+// delivers it.
+func (dev *Device) serveEvent() error {
+	var (
+		event input.Event
+		err   error
+	)
+
+	err = binary.Read(dev.file, binary.NativeEndian, &event)
+	if err != nil {
+		return inputerr.Wrap("failed to read uinput events", dev.file.Name(), err)
+	}
+
+	if event.Type != uinput.EV_UINPUT {
+		dev.handle(event)
+
+		return nil
+	}
+
+	switch event.Code {
+	case uinput.UI_FF_UPLOAD:
+		return dev.uploadFF(event.Value)
+	case uinput.UI_FF_ERASE:
+		return dev.eraseFF(event.Value)
+	}
+
+	return nil
+}
+
+func (dev *Device) writeEvents(events []input.Event) error {
+	var (
+		buf bytes.Buffer
+		err error
+	)
+
+	err = binary.Write(&buf, binary.NativeEndian, events)
+	if err != nil {
+		return fmt.Errorf("%s: failed to encode events: %w", dev.file.Name(), err)
+	}
+
+	_, err = dev.file.Write(buf.Bytes())
+	if err != nil {
+		return inputerr.Wrap("failed to write uinput events", dev.file.Name(), err)
+	}
+
+	return nil
+}
+
+func (dev *Device) validate() error {
+	var (
+		event   input.EventCode
+		absInfo uinput.AbsSetup
+		span    int64
+	)
+
+	if dev.setup.Name[0] == 0 {
+		return fmt.Errorf("failed to create uinput device: empty name: %w", ErrInvalidSetup)
+	}
+
+	for _, event = range slices.Sorted(maps.Keys(dev.codes)) {
+		if !dev.events[event] {
+			return fmt.Errorf(
+				"failed to create uinput device: codes set for %s without enabling it: %w",
+				event,
+				ErrInvalidSetup,
+			)
+		}
+	}
+
+	for _, absInfo = range dev.absInfos {
+		span = int64(absInfo.AbsInfo.Maximum) - int64(absInfo.AbsInfo.Minimum)
+
+		switch {
+		case span < 0:
+			return fmt.Errorf(
+				"failed to create uinput device: %s: minimum %d above maximum %d: %w",
+				absInfo.Code,
+				absInfo.AbsInfo.Minimum,
+				absInfo.AbsInfo.Maximum,
+				ErrInvalidSetup,
+			)
+		case int64(absInfo.AbsInfo.Flat) > span:
+			return fmt.Errorf(
+				"failed to create uinput device: %s: flat %d wider than range %d: %w",
+				absInfo.Code,
+				absInfo.AbsInfo.Flat,
+				span,
+				ErrInvalidSetup,
+			)
+		}
+	}
+
+	if dev.events[input.EV_FF] && (dev.setup.FFEffectsMax == 0 || dev.ffHandler == nil) {
+		return fmt.Errorf(
+			"failed to create uinput device: %s enabled without SetFFHandler: %w",
+			input.EV_FF,
+			ErrInvalidSetup,
+		)
+	}
+
+	return nil
+}
 
-			break
+func (dev *Device) setupAbs() error {
+	var (
+		absInfo uinput.AbsSetup
+		err     error
+	)
+
+	for _, absInfo = range dev.absInfos {
+		err = ioctlwrap.SetAny(
+			dev.file,
+			uinput.UI_ABS_SETUP,
+			&absInfo,
+			"failed to set uinput device absolute infos",
+		)
+		if err != nil {
+			return err
 		}
 	}
 
-	close(dev.uploadChan)
-	close(dev.eraseChan)
-	close(dev.errChan)
+	return nil
+}
+
+func (dev *Device) setupLegacy() error {
+	var (
+		userDev uinput.UserDev
+		absInfo uinput.AbsSetup
+		codes   []input.AbsoluteCode
+		err     error
+	)
+
+	userDev.Name = dev.setup.Name
+	userDev.ID = dev.setup.ID
+	userDev.FFEffectsMax = dev.setup.FFEffectsMax
+	codes = make([]input.AbsoluteCode, 0, len(dev.absInfos))
+
+	for _, absInfo = range dev.absInfos {
+		userDev.AbsMin[absInfo.Code] = absInfo.AbsInfo.Minimum
+		userDev.AbsMax[absInfo.Code] = absInfo.AbsInfo.Maximum
+		userDev.AbsFuzz[absInfo.Code] = absInfo.AbsInfo.Fuzz
+		userDev.AbsFlat[absInfo.Code] = absInfo.AbsInfo.Flat
+		codes = append(codes, absInfo.Code)
+	}
+
+	err = dev.SetAbsCodes(codes)
+	if err != nil {
+		return err
+	}
+
+	err = binary.Write(dev.file, binary.NativeEndian, &userDev)
+	if err != nil {
+		return inputerr.Wrap("failed to write legacy uinput device setup", dev.file.Name(), err)
+	}
+
+	return nil
+}
+
+// uiSetUniq returns the request code of UI_SET_UNIQ, which is missing
+// from the uinput header of mainline kernels.
+func uiSetUniq() (uint32, error) {
+	return ioctlwrap.IOW[*byte](uinput.UINPUT_IOCTL_BASE, 111, "uinput.UI_SET_UNIQ")
+}
+
+func enableCodes[T input.Code](
+	dev *Device,
+	fn func() (uint32, error),
+	event input.EventCode,
+	codes []T,
+	errMsg string,
+) error {
+	var err error
+
+	err = setCodes(dev.file, fn, codes, errMsg)
+	if err != nil {
+		return err
+	}
+
+	dev.enable(event, input.AsCoders(codes))
+
+	return nil
+}
+
+func (dev *Device) enable(event input.EventCode, codes []input.Coder) {
+	var code input.Coder
+
+	if len(codes) == 0 {
+		return
+	}
+
+	if dev.codes[event] == nil {
+		dev.codes[event] = make(map[uint16]bool, len(codes))
+	}
+
+	for _, code = range codes {
+		dev.codes[event][code.Value()] = true
+	}
 }
 
 func setCodes[T input.Code](
@@ -436,3 +890,38 @@ func setCodes[T input.Code](
 
 	return nil
 }
+
+func newDevice(id input.ID, name string) (*Device, error) {
+	var buf [uinput.UINPUT_MAX_NAME_SIZE]byte
+
+	if len(name)+1 > uinput.UINPUT_MAX_NAME_SIZE {
+		return nil, fmt.Errorf(
+			"failed to allocate uinput device: name is %d bytes long, max is %d bytes including the null terminator: %w",
+			len(name),
+			uinput.UINPUT_MAX_NAME_SIZE,
+			ErrNameTooLong,
+		)
+	}
+
+	copy(buf[:], name)
+
+	return &Device{
+		setup: uinput.Setup{
+			ID:   id,
+			Name: buf,
+		},
+		events: make(map[input.EventCode]bool),
+		codes:  make(map[input.EventCode]map[uint16]bool),
+	}, nil
+}
+
+func checkFd(fd uintptr, name string) error {
+	var err error
+
+	_, err = ioctl.GetAny(fd, uinput.UI_GET_VERSION, new(uint32))
+	if err != nil {
+		return inputerr.Wrap("failed to adopt uinput device", name, err)
+	}
+
+	return nil
+}
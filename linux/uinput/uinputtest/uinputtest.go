@@ -0,0 +1,90 @@
+// Package uinputtest provides a fake [uinput.Emitter] for testing code
+// that emits events to uinput devices.
+package uinputtest
+
+import (
+	"fmt"
+	"slices"
+	"sync"
+
+	"github.com/andrieee44/gopkg/linux/uapi/input"
+	"github.com/andrieee44/gopkg/linux/uinput"
+)
+
+// Recorder is a [uinput.Emitter] recording the batches emitted to it
+// instead of sending them to a device. The zero value is ready to use. It
+// is safe for concurrent use.
+type Recorder struct {
+	batches [][]input.Event
+	err     error
+	mu      sync.Mutex
+}
+
+var _ uinput.Emitter = (*Recorder)(nil)
+
+// EmitBatch records a copy of events, or returns the error set with
+// [Recorder.SetErr] without recording them.
+func (rec *Recorder) EmitBatch(events []input.Event) error {
+	rec.mu.Lock()
+	defer rec.mu.Unlock()
+
+	if rec.err != nil {
+		return rec.err
+	}
+
+	rec.batches = append(rec.batches, slices.Clone(events))
+
+	return nil
+}
+
+// SetErr makes [Recorder.EmitBatch] fail with err, or succeed again if
+// err is nil.
+func (rec *Recorder) SetErr(err error) {
+	rec.mu.Lock()
+	defer rec.mu.Unlock()
+
+	rec.err = err
+}
+
+// Batches returns the batches recorded so far.
+func (rec *Recorder) Batches() [][]input.Event {
+	rec.mu.Lock()
+	defer rec.mu.Unlock()
+
+	return slices.Clone(rec.batches)
+}
+
+// Events returns the events recorded so far, in order.
+func (rec *Recorder) Events() []input.Event {
+	rec.mu.Lock()
+	defer rec.mu.Unlock()
+
+	return slices.Concat(rec.batches...)
+}
+
+// Frames returns the key, relative and absolute events recorded so far
+// as "CODE value" strings, such as "KEY_A 1", split into frames at each
+// synchronisation event. Other events are left out.
+func (rec *Recorder) Frames() [][]string {
+	var (
+		frames [][]string
+		frame  []string
+		event  input.Event
+	)
+
+	for _, event = range rec.Events() {
+		switch event.Type {
+		case input.EV_SYN:
+			frames = append(frames, frame)
+			frame = nil
+		case input.EV_KEY:
+			frame = append(frame, fmt.Sprintf("%s %d", input.KeyCode(event.Code), event.Value))
+		case input.EV_REL:
+			frame = append(frame, fmt.Sprintf("%s %d", input.RelativeCode(event.Code), event.Value))
+		case input.EV_ABS:
+			frame = append(frame, fmt.Sprintf("%s %d", input.AbsoluteCode(event.Code), event.Value))
+		}
+	}
+
+	return frames
+}
@@ -26,18 +26,34 @@ func xdg(env string, subPaths ...string) string {
 	return env
 }
 
-func xdgFile(xdgPath, relPath string) (*os.File, error) {
+func xdgPath(xdgDir, relPath string) (string, error) {
 	const userOnly os.FileMode = 0o700
 
 	var (
-		file *os.File
 		path string
 		err  error
 	)
 
-	path = filepath.Join(xdgPath, relPath)
+	path = filepath.Join(xdgDir, relPath)
 
 	err = os.MkdirAll(filepath.Dir(path), userOnly)
+	if err != nil {
+		return "", fmt.Errorf("xdg.xdgPath: %w", err)
+	}
+
+	return path, nil
+}
+
+func xdgFile(xdgDir, relPath string) (*os.File, error) {
+	const userOnly os.FileMode = 0o700
+
+	var (
+		file *os.File
+		path string
+		err  error
+	)
+
+	path, err = xdgPath(xdgDir, relPath)
 	if err != nil {
 		return nil, fmt.Errorf("xdg.xdgFile: %w", err)
 	}
@@ -222,3 +238,12 @@ func CacheFile(relPath string) (*os.File, error) {
 func RuntimeFile(relPath string) (*os.File, error) {
 	return xdgFile(xdg("XDG_RUNTIME_DIR", "/tmp"), relPath)
 }
+
+// RuntimePath returns the path of relPath (e.g., "appname/app.sock")
+// under the base runtime directory described in [RuntimeFile], creating
+// missing directories but not the file itself. Use it for file objects
+// that cannot be opened as regular files, such as sockets and named
+// pipes.
+func RuntimePath(relPath string) (string, error) {
+	return xdgPath(xdg("XDG_RUNTIME_DIR", "/tmp"), relPath)
+}